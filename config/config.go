@@ -10,6 +10,53 @@ var DBName = os.Getenv("DB.NAME")
 var BaseURL = os.Getenv("BASE_URL")
 var SecretKey = os.Getenv("JWT.SECRET")
 
+// TrashRetentionDays is how long soft-deleted content stays in the trash before it is purged.
+var TrashRetentionDays = 30
+
+// CommentMaxDepth is how many levels a comment thread may have; replies
+// beyond it are attached next to the comment they answer.
+var CommentMaxDepth = 3
+
+// CommentModeration is the default moderation mode: "pre" holds new comments
+// until staff approve them, "post" publishes them right away. Categories can
+// override it through the moderation settings endpoint.
+var CommentModeration = "pre"
+
+// CommentTrustedAfter is how many approved comments a logged-in author needs
+// before their comments skip the moderation queue.
+var CommentTrustedAfter = 3
+
+// CommentEditWindowMinutes is how long logged-in authors can edit or delete
+// their own comments. Staff can change any comment at any time.
+var CommentEditWindowMinutes = 15
+
+// CommentReportThreshold is how many reports by signed-in readers hide a
+// published comment until staff review it again.
+var CommentReportThreshold = 3
+
+// SpamThreshold is the content-filter score at which comments and candidate
+// submissions are stored as spam instead of going through normally.
+var SpamThreshold = 1.0
+
+// SpamExtraWords is a comma-separated list of words to flag on top of the
+// built-in profanity list.
+var SpamExtraWords = ""
+
+// CaptchaMode is the kind of challenge public forms must solve: "pow",
+// "arithmetic" or "off".
+var CaptchaMode = "pow"
+
+// CaptchaDifficulty is the number of leading zero bits a proof-of-work
+// solution needs, or the largest operand of an arithmetic question.
+var CaptchaDifficulty = 16
+
+// TourTimezone is the time zone campus tour slots are defined in.
+var TourTimezone = "Asia/Ho_Chi_Minh"
+
+// WordPressMediaDir is a local copy of a WordPress export's wp-content/uploads
+// folder. The importer copies media from it before falling back to downloading.
+var WordPressMediaDir = ""
+
 type Config struct {
 	ServerPort string
 	DBUser     string
@@ -19,6 +66,19 @@ type Config struct {
 	DBPort     string
 	BaseURL    string
 	SecretKey  string
+
+	TrashRetentionDays  int
+	WordPressMediaDir   string
+	CommentMaxDepth     int
+	CommentModeration   string
+	CommentTrustedAfter int
+	CommentEditWindow   int
+	CommentReportLimit  int
+	SpamThreshold       float64
+	SpamExtraWords      string
+	CaptchaMode         string
+	CaptchaDifficulty   int
+	TourTimezone        string
 }
 
 func LoadConfig() (*Config, error) {
@@ -35,6 +95,16 @@ func LoadConfig() (*Config, error) {
 
 	viper.SetConfigType("env")
 	viper.SetDefault("sever.port", "8089")
+	viper.SetDefault("TRASH.RETENTION_DAYS", 30)
+	viper.SetDefault("COMMENT.MAX_DEPTH", 3)
+	viper.SetDefault("COMMENT.MODERATION", "pre")
+	viper.SetDefault("COMMENT.TRUSTED_AFTER", 3)
+	viper.SetDefault("COMMENT.EDIT_WINDOW_MINUTES", 15)
+	viper.SetDefault("COMMENT.REPORT_THRESHOLD", 3)
+	viper.SetDefault("SPAM.THRESHOLD", 1.0)
+	viper.SetDefault("CAPTCHA.MODE", "pow")
+	viper.SetDefault("CAPTCHA.DIFFICULTY", 16)
+	viper.SetDefault("TOUR.TIMEZONE", "Asia/Ho_Chi_Minh")
 
 	if err := viper.ReadInConfig(); err != nil {
 		log.Printf("Error reading config file, %s", err)
@@ -51,9 +121,34 @@ func LoadConfig() (*Config, error) {
 		DBPort:     viper.GetString("DB.PORT"),
 		BaseURL:    viper.GetString("BASE_URL"),
 		SecretKey:  viper.GetString("JWT.SECRET"),
+
+		TrashRetentionDays:  viper.GetInt("TRASH.RETENTION_DAYS"),
+		WordPressMediaDir:   viper.GetString("WORDPRESS.MEDIA_DIR"),
+		CommentMaxDepth:     viper.GetInt("COMMENT.MAX_DEPTH"),
+		CommentModeration:   viper.GetString("COMMENT.MODERATION"),
+		CommentTrustedAfter: viper.GetInt("COMMENT.TRUSTED_AFTER"),
+		CommentEditWindow:   viper.GetInt("COMMENT.EDIT_WINDOW_MINUTES"),
+		CommentReportLimit:  viper.GetInt("COMMENT.REPORT_THRESHOLD"),
+		SpamThreshold:       viper.GetFloat64("SPAM.THRESHOLD"),
+		SpamExtraWords:      viper.GetString("SPAM.EXTRA_WORDS"),
+		CaptchaMode:         viper.GetString("CAPTCHA.MODE"),
+		CaptchaDifficulty:   viper.GetInt("CAPTCHA.DIFFICULTY"),
+		TourTimezone:        viper.GetString("TOUR.TIMEZONE"),
 	}
 	DBName = config.DBName
 	BaseURL = config.BaseURL
 	SecretKey = config.SecretKey
+	TrashRetentionDays = config.TrashRetentionDays
+	WordPressMediaDir = config.WordPressMediaDir
+	CommentMaxDepth = config.CommentMaxDepth
+	CommentModeration = config.CommentModeration
+	CommentTrustedAfter = config.CommentTrustedAfter
+	CommentEditWindowMinutes = config.CommentEditWindow
+	CommentReportThreshold = config.CommentReportLimit
+	SpamThreshold = config.SpamThreshold
+	SpamExtraWords = config.SpamExtraWords
+	CaptchaMode = config.CaptchaMode
+	CaptchaDifficulty = config.CaptchaDifficulty
+	TourTimezone = config.TourTimezone
 	return config, nil
 }
@@ -0,0 +1,75 @@
+package cronjobs
+
+import (
+	"amg-backend/config"
+	"amg-backend/models"
+	"context"
+	"log"
+	"os"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func RunAttachmentCleanupJob(dbClient *mongo.Client) {
+	log.Println("--- [CRON] Starting attachment cleanup job ---")
+
+	attachmentCollection := dbClient.Database(config.DBName).Collection("Attachment")
+
+	cutoffTime := time.Now().Add(GracePeriod)
+	log.Printf("[CRON] Cleanup threshold: Deleting 'pending' attachments created before %s\n", cutoffTime.Format(time.RFC3339))
+
+	filter := bson.M{
+		"status": models.AttachmentStatusPending,
+		"createdAt": bson.M{
+			"$lt": cutoffTime,
+		},
+	}
+
+	cursor, err := attachmentCollection.Find(context.TODO(), filter)
+	if err != nil {
+		log.Printf("[CRON-ERROR] Failed to find attachments for cleanup: %v\n", err)
+		return
+	}
+	defer cursor.Close(context.TODO())
+
+	var attachmentsToDelete []models.Attachment
+	if err = cursor.All(context.TODO(), &attachmentsToDelete); err != nil {
+		log.Printf("[CRON-ERROR] Failed to decode attachments: %v\n", err)
+		return
+	}
+
+	if len(attachmentsToDelete) == 0 {
+		log.Println("[CRON] No old pending attachments found to delete. Job finished.")
+		return
+	}
+
+	log.Printf("[CRON] Found %d attachments to delete.\n", len(attachmentsToDelete))
+	deletedCount := 0
+	errorCount := 0
+
+	for _, attachment := range attachmentsToDelete {
+		if err := os.Remove(attachment.Path); err != nil {
+			if os.IsNotExist(err) {
+				log.Printf("[CRON-WARN] File not found, will still remove DB record: %s\n", attachment.Path)
+			} else {
+				log.Printf("[CRON-ERROR] Failed deleting file %s: %v\n", attachment.Path, err)
+				errorCount++
+				continue
+			}
+		}
+
+		_, err = attachmentCollection.DeleteOne(context.TODO(), bson.M{"_id": attachment.ID})
+		if err != nil {
+			log.Printf("[CRON-ERROR] Failed deleting DB record for attachment ID %s: %v\n", attachment.ID.Hex(), err)
+			errorCount++
+			continue
+		}
+
+		log.Printf("[CRON] Successfully deleted attachment and DB record for: %s\n", attachment.OriginalName)
+		deletedCount++
+	}
+
+	log.Printf("--- [CRON] Attachment cleanup job finished. Deleted: %d. Errors: %d. ---\n", deletedCount, errorCount)
+}
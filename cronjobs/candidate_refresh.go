@@ -0,0 +1,30 @@
+package cronjobs
+
+import (
+	"amg-backend/service"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// RunCandidateRefreshJob fills in the data newer code derives from a
+// candidate's form for candidates saved before it existed, and moves children
+// into their age group for the current school year. It only writes candidates
+// that change, so running it again is cheap.
+func RunCandidateRefreshJob(dbClient *mongo.Client) {
+	log.Println("--- [CRON] Starting candidate refresh ---")
+
+	keys, err := service.BackfillCandidateMatchKeys(dbClient)
+	if err != nil {
+		log.Printf("[CRON-ERROR] Candidate match key backfill failed: %v\n", err)
+		return
+	}
+	refreshed, err := service.RefreshStoredCandidates(dbClient, time.Now())
+	if err != nil {
+		log.Printf("[CRON-ERROR] Candidate refresh failed: %v\n", err)
+		return
+	}
+
+	log.Printf("--- [CRON] Candidate refresh finished. Match keys added: %d. Candidates updated: %d. ---\n", keys, refreshed)
+}
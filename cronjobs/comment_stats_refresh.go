@@ -0,0 +1,23 @@
+package cronjobs
+
+import (
+	"amg-backend/service"
+	"log"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// RunCommentStatsRefreshJob recounts the comments of every post. The counts
+// are kept up to date as comments change; this corrects any drift and fills
+// them in for posts that predate them.
+func RunCommentStatsRefreshJob(dbClient *mongo.Client) {
+	log.Println("--- [CRON] Starting comment stats refresh ---")
+
+	posts, err := service.RefreshAllPostCommentStats(dbClient)
+	if err != nil {
+		log.Printf("[CRON-ERROR] Comment stats refresh failed: %v\n", err)
+		return
+	}
+
+	log.Printf("--- [CRON] Comment stats refresh finished. Posts with comments: %d. ---\n", posts)
+}
@@ -0,0 +1,23 @@
+package cronjobs
+
+import (
+	"amg-backend/service"
+	"log"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// RunIntegrityCheckJob looks for missing images and links to deleted posts.
+// It runs after the cleanup jobs so their removals show up in the report.
+func RunIntegrityCheckJob(dbClient *mongo.Client) {
+	log.Println("--- [CRON] Starting content integrity check ---")
+
+	report, err := service.RunIntegrityCheck(dbClient)
+	if err != nil {
+		log.Printf("[CRON-ERROR] Integrity check failed: %v\n", err)
+		return
+	}
+
+	log.Printf("--- [CRON] Integrity check finished. Posts: %d. References: %d. Issues: %d. ---\n",
+		report.PostsScanned, report.ReferencesChecked, len(report.Issues))
+}
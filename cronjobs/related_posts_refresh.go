@@ -0,0 +1,21 @@
+package cronjobs
+
+import (
+	"amg-backend/service"
+	"log"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// RunRelatedPostsRefreshJob rebuilds the related posts cache so that posts
+// which changed status since the last save are picked up.
+func RunRelatedPostsRefreshJob(dbClient *mongo.Client) {
+	log.Println("--- [CRON] Starting related posts refresh job ---")
+
+	if err := service.RefreshRelatedPosts(dbClient); err != nil {
+		log.Printf("[CRON-ERROR] Failed to refresh related posts: %v\n", err)
+		return
+	}
+
+	log.Println("--- [CRON] Related posts refresh job finished. ---")
+}
@@ -0,0 +1,22 @@
+package cronjobs
+
+import (
+	"amg-backend/service"
+	"log"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// RunTourSlotGenerationJob creates the tour slots of the day that has just
+// come within the booking window. Public listings only read slots, so a rule
+// has bookable slots only as far ahead as this job and rule changes made them.
+func RunTourSlotGenerationJob(dbClient *mongo.Client) {
+	log.Println("--- [CRON] Starting tour slot generation ---")
+
+	if err := service.GenerateTourSlots(dbClient); err != nil {
+		log.Printf("[CRON-ERROR] Tour slot generation failed: %v\n", err)
+		return
+	}
+
+	log.Println("--- [CRON] Tour slot generation finished. ---")
+}
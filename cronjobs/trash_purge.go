@@ -0,0 +1,23 @@
+package cronjobs
+
+import (
+	"amg-backend/config"
+	"amg-backend/service"
+	"log"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// RunTrashPurgeJob permanently deletes content that has been in the trash for
+// longer than TRASH.RETENTION_DAYS. Images and attachments of purged posts are
+// marked 'pending' and removed by the cleanup jobs.
+func RunTrashPurgeJob(dbClient *mongo.Client) {
+	log.Printf("--- [CRON] Starting trash purge job (retention: %d days) ---\n", config.TrashRetentionDays)
+
+	purged, err := service.PurgeExpiredTrash(dbClient)
+	if err != nil {
+		log.Printf("[CRON-ERROR] Trash purge stopped early: %v\n", err)
+	}
+
+	log.Printf("--- [CRON] Trash purge job finished. Purged: %d. ---\n", purged)
+}
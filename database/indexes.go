@@ -0,0 +1,135 @@
+package database
+
+import (
+	"amg-backend/config"
+	"amg-backend/models"
+	"context"
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"time"
+)
+
+// EnsureIndexes creates the indexes the handlers rely on for uniqueness and expiry.
+// Creating an index that already exists is a no-op in MongoDB. Every collection
+// is attempted and the errors are returned together.
+func EnsureIndexes(client *mongo.Client) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	db := client.Database(config.DBName)
+	indexes := map[string][]mongo.IndexModel{
+		"PostView": {
+			{
+				Keys:    bson.D{{Key: "post_id", Value: 1}, {Key: "visitor_id", Value: 1}, {Key: "day", Value: 1}},
+				Options: options.Index().SetUnique(true),
+			},
+			{
+				// A visitor only needs to be remembered for the day they were counted
+				Keys:    bson.D{{Key: "created_at", Value: 1}},
+				Options: options.Index().SetExpireAfterSeconds(int32((48 * time.Hour).Seconds())),
+			},
+		},
+		"Post": {
+			{
+				// Lets importers recognise posts they created on an earlier run
+				Keys: bson.D{{Key: "legacy_id", Value: 1}},
+				Options: options.Index().SetUnique(true).
+					SetPartialFilterExpression(bson.M{"legacy_id": bson.M{"$exists": true}}),
+			},
+		},
+		"Comment": {
+			{
+				Keys: bson.D{{Key: "post_id", Value: 1}},
+			},
+			{
+				// Moderation queue
+				Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: 1}},
+			},
+		},
+		"CaptchaSolution": {
+			{
+				// Solved challenges only need to be remembered until they expire
+				Keys:    bson.D{{Key: "used_at", Value: 1}},
+				Options: options.Index().SetExpireAfterSeconds(int32((2 * time.Hour).Seconds())),
+			},
+		},
+		"Candidate": {
+			{
+				// Duplicate detection
+				Keys: bson.D{{Key: "match_keys.phone", Value: 1}},
+			},
+			{
+				Keys: bson.D{{Key: "match_keys.parent_name", Value: 1}, {Key: "match_keys.dob", Value: 1}},
+			},
+		},
+		"CandidateActivity": {
+			{
+				Keys: bson.D{{Key: "candidate_id", Value: 1}, {Key: "created_at", Value: -1}},
+			},
+		},
+		"TourSlot": {
+			{
+				// Slots are generated idempotently per rule and start time
+				Keys:    bson.D{{Key: "rule_id", Value: 1}, {Key: "start_at", Value: 1}},
+				Options: options.Index().SetUnique(true),
+			},
+			{
+				Keys: bson.D{{Key: "start_at", Value: 1}},
+			},
+		},
+		"TourBooking": {
+			{
+				// One active booking per candidate
+				Keys: bson.D{{Key: "candidate_id", Value: 1}},
+				Options: options.Index().SetUnique(true).
+					SetPartialFilterExpression(bson.M{"status": models.TourBookingBooked}),
+			},
+			{
+				Keys: bson.D{{Key: "start_at", Value: 1}},
+			},
+		},
+		"CommentRevision": {
+			{
+				Keys: bson.D{{Key: "comment_id", Value: 1}, {Key: "created_at", Value: 1}},
+			},
+		},
+		"CommentReaction": {
+			{
+				// One reaction per user and comment
+				Keys:    bson.D{{Key: "comment_id", Value: 1}, {Key: "user_id", Value: 1}},
+				Options: options.Index().SetUnique(true),
+			},
+		},
+		"CommentReport": {
+			{
+				// A reader can report a comment once
+				Keys:    bson.D{{Key: "comment_id", Value: 1}, {Key: "reporter", Value: 1}},
+				Options: options.Index().SetUnique(true),
+			},
+		},
+		"PostViewDaily": {
+			{
+				Keys:    bson.D{{Key: "post_id", Value: 1}, {Key: "day", Value: 1}},
+				Options: options.Index().SetUnique(true),
+			},
+			{
+				Keys: bson.D{{Key: "day", Value: 1}},
+			},
+		},
+	}
+
+	// One bad index must not leave the other collections without theirs
+	var errs []error
+	for collection, specs := range indexes {
+		if _, err := db.Collection(collection).Indexes().CreateMany(ctx, specs); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", collection, err))
+			continue
+		}
+		log.Printf("Indexes ensured for %s.", collection)
+	}
+	return errors.Join(errs...)
+}
@@ -15,9 +15,9 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/amg/v1/auth/login": {
+        "/amg/v1/attachments/delete-attachment/{id}": {
             "post": {
-                "description": "Authenticate user with username and password",
+                "description": "Detaches an attachment from its post and marks it 'pending' so that the cleanup job removes it. Staff only.",
                 "consumes": [
                     "application/json"
                 ],
@@ -25,29 +25,30 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "attachment"
                 ],
-                "summary": "Login user",
+                "summary": "Delete an attachment",
                 "parameters": [
                     {
-                        "description": "Login credentials",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/auth.LoginRequest"
-                        }
+                        "type": "string",
+                        "description": "Attachment ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.User"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     },
                     "400": {
-                        "description": "Invalid input",
+                        "description": "Bad Request",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -55,57 +56,85 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
                                 "type": "string"
                             }
                         }
-                    }
-                }
-            }
-        },
-        "/amg/v1/auth/logout": {
-            "post": {
-                "description": "Clear the session token cookie",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "auth"
-                ],
-                "summary": "Logout user",
-                "responses": {
-                    "200": {
-                        "description": "OK",
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
-                            "type": "objects"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     }
                 }
             }
         },
-        "/amg/v1/auth/me": {
+        "/amg/v1/attachments/download/{id}": {
             "get": {
-                "description": "Get user info from session token cookie",
+                "description": "Sends the attachment with its original filename. Use inline=true to let the browser display PDFs. Attachments of a post that is not published are sent with the post's preview token; the URLs in /posts/preview already carry it.",
                 "produces": [
-                    "application/json"
+                    "application/octet-stream"
                 ],
                 "tags": [
-                    "auth"
+                    "attachment"
+                ],
+                "summary": "Download an attachment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Attachment ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Display in the browser instead of downloading",
+                        "name": "inline",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Preview token of the attachment's post",
+                        "name": "token",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Get current logged-in user's info",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.User"
+                            "type": "file"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -116,9 +145,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/amg/v1/auth/register": {
-            "post": {
-                "description": "Register a new user",
+        "/amg/v1/attachments/get-attachments-by-post/{postId}": {
+            "get": {
+                "description": "Retrieves the attachments linked to a post",
                 "consumes": [
                     "application/json"
                 ],
@@ -126,23 +155,30 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "attachment"
                 ],
-                "summary": "Register a new user",
+                "summary": "Get the attachments of a post",
                 "parameters": [
                     {
-                        "description": "User data",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/auth.RegisterRequest"
-                        }
+                        "type": "string",
+                        "description": "Post ID",
+                        "name": "postId",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Attachment"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -150,8 +186,8 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -171,35 +207,33 @@ const docTemplate = `{
                 }
             }
         },
-        "/amg/v1/candidates/create-candidate": {
+        "/amg/v1/attachments/upload-attachment": {
             "post": {
-                "description": "Creates a new candidate in the database",
+                "description": "Uploads a PDF or Office document and returns its record. The attachment stays 'pending' until a post references it through attachment_ids. Staff only.",
                 "consumes": [
-                    "application/json"
+                    "multipart/form-data"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "candidate"
+                    "attachment"
                 ],
-                "summary": "Create a new candidate",
+                "summary": "Upload a document attachment",
                 "parameters": [
                     {
-                        "description": "Candidate data",
-                        "name": "candidate",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.Candidate"
-                        }
+                        "type": "file",
+                        "description": "Document to upload (PDF, DOC(X), XLS(X), PPT(X))",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Candidate"
+                            "$ref": "#/definitions/models.Attachment"
                         }
                     },
                     "400": {
@@ -211,6 +245,15 @@ const docTemplate = `{
                             }
                         }
                     },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -223,9 +266,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/amg/v1/candidates/delete-candidate/{id}": {
+        "/amg/v1/auth/login": {
             "post": {
-                "description": "Deletes a candidate by its ID",
+                "description": "Authenticate user with username and password",
                 "consumes": [
                     "application/json"
                 ],
@@ -233,30 +276,29 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "candidate"
+                    "auth"
                 ],
-                "summary": "Delete a candidate",
+                "summary": "Login user",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Candidate ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Login credentials",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/auth.LoginRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/models.User"
                         }
                     },
                     "400": {
-                        "description": "Bad Request",
+                        "description": "Invalid input",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -264,8 +306,8 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -276,31 +318,45 @@ const docTemplate = `{
                 }
             }
         },
-        "/amg/v1/candidates/get-all-candidates": {
-            "get": {
-                "description": "Retrieves all candidates from the database",
-                "consumes": [
+        "/amg/v1/auth/logout": {
+            "post": {
+                "description": "Clear the session token cookie",
+                "produces": [
                     "application/json"
                 ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Logout user",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "objects"
+                        }
+                    }
+                }
+            }
+        },
+        "/amg/v1/auth/me": {
+            "get": {
+                "description": "Get user info from session token cookie",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "candidate"
+                    "auth"
                 ],
-                "summary": "Get all candidate",
+                "summary": "Get current logged-in user's info",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Candidate"
-                            }
+                            "$ref": "#/definitions/models.User"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -311,9 +367,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/amg/v1/candidates/get-candidate/{id}": {
-            "get": {
-                "description": "Retrieves a candidate by its ID",
+        "/amg/v1/auth/register": {
+            "post": {
+                "description": "Register a new user",
                 "consumes": [
                     "application/json"
                 ],
@@ -321,27 +377,23 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "candidate"
+                    "auth"
                 ],
-                "summary": "Get candidate by ID",
+                "summary": "Register a new user",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Candidate ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "User data",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/auth.RegisterRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/models.Candidate"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -349,8 +401,8 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -370,9 +422,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/amg/v1/candidates/get-candidates-by-status/{status}": {
-            "get": {
-                "description": "Retrieves candidates by their status",
+        "/amg/v1/candidates/add-activity/{id}": {
+            "post": {
+                "description": "Records a note, call or email with a candidate's family, with the channel used and optionally when to follow up. The entry becomes the candidate's latest activity. Status changes are recorded by transition-candidate. Staff only.",
                 "consumes": [
                     "application/json"
                 ],
@@ -382,24 +434,30 @@ const docTemplate = `{
                 "tags": [
                     "candidate"
                 ],
-                "summary": "Get candidates by status",
+                "summary": "Add an entry to a candidate's timeline",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Candidate Status",
-                        "name": "status",
+                        "description": "Candidate ID",
+                        "name": "id",
                         "in": "path",
                         "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
+                    },
+                    {
+                        "description": "Activity",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Candidate"
-                            }
+                            "$ref": "#/definitions/models.AddCandidateActivityPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.CandidateActivity"
                         }
                     },
                     "400": {
@@ -411,6 +469,15 @@ const docTemplate = `{
                             }
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -423,9 +490,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/amg/v1/candidates/recovery-candidate/{id}": {
+        "/amg/v1/candidates/create-candidate": {
             "post": {
-                "description": "Recovers a candidate by its ID",
+                "description": "Creates a new candidate in the database. student_name and phone are required. The phone must be a Vietnamese mobile or landline number and is saved in E.164 (+84...); dob is read day first (25/12/2021, 1/2/20 or 2021-12-25), saved as YYYY-MM-DD with birth_date, and sets the child's kindergarten age_group (nha_tre, mam, choi or la). Invalid fields are listed in 'fields' of a 400 response, e.g. {\"error\": \"...\", \"fields\": {\"phone\": \"...\"}}. Submissions flagged by the content filters (profanity, links, repetition, or a filled-in 'website' honeypot field) are stored with status 'spam' for staff to review. Submissions with the same phone number, or the same parent name and child's date of birth, as an existing candidate are saved with possible_duplicates set for staff to merge or dismiss. An optional 'tour_slot_id' from /tours/get-available-slots books a campus tour together with the form; if the slot is full nothing is saved and 409 is returned.",
                 "consumes": [
                     "application/json"
                 ],
@@ -435,24 +502,35 @@ const docTemplate = `{
                 "tags": [
                     "candidate"
                 ],
-                "summary": "Recover a deleted candidate",
+                "summary": "Create a new candidate",
                 "parameters": [
+                    {
+                        "description": "Candidate data",
+                        "name": "candidate",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Candidate"
+                        }
+                    },
                     {
                         "type": "string",
-                        "description": "Candidate ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Token from /captcha/get-challenge; not needed when logged in",
+                        "name": "X-Captcha-Token",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Nonce or answer solving the challenge",
+                        "name": "X-Captcha-Solution",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/models.Candidate"
                         }
                     },
                     "400": {
@@ -464,52 +542,17 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
                                 "type": "string"
                             }
                         }
-                    }
-                }
-            }
-        },
-        "/amg/v1/candidates/update-candidate/{id}": {
-            "post": {
-                "description": "Updates a candidate's information by its ID",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "candidate"
-                ],
-                "summary": "Update a candidate",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Candidate ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
                     },
-                    {
-                        "description": "Candidate data to update",
-                        "name": "candidate",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.Candidate"
-                        }
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -517,8 +560,8 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -538,9 +581,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/amg/v1/comments/create-comment": {
+        "/amg/v1/candidates/delete-candidate/{id}": {
             "post": {
-                "description": "Create a new comment for a post",
+                "description": "Moves a candidate to the trash. It is purged permanently after the retention period. Staff only.",
                 "consumes": [
                     "application/json"
                 ],
@@ -548,25 +591,26 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Comment"
+                    "candidate"
                 ],
-                "summary": "Create a new comment",
+                "summary": "Delete a candidate",
                 "parameters": [
                     {
-                        "description": "Data to create a comment",
-                        "name": "comment",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.CreateCommentPayload"
-                        }
+                        "type": "string",
+                        "description": "Candidate ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Comment"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     },
                     "400": {
@@ -578,6 +622,15 @@ const docTemplate = `{
                             }
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -590,9 +643,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/amg/v1/comments/delete-comment/{id}": {
+        "/amg/v1/candidates/dismiss-duplicates/{id}": {
             "post": {
-                "description": "Mark a comment as deleted by ID",
+                "description": "Marks a flagged candidate as a different family from the candidates it matched. Staff only.",
                 "consumes": [
                     "application/json"
                 ],
@@ -600,13 +653,13 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Comment"
+                    "candidate"
                 ],
-                "summary": "Delete a comment",
+                "summary": "Clear a candidate's duplicate flag",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Comment ID",
+                        "description": "Candidate ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -652,9 +705,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/amg/v1/comments/get-comments-by-post": {
+        "/amg/v1/candidates/get-activities/{id}": {
             "get": {
-                "description": "Get all comments for a specific post",
+                "description": "Lists the notes, calls, emails and status changes of a candidate, newest first. Staff only.",
                 "consumes": [
                     "application/json"
                 ],
@@ -662,15 +715,15 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Comment"
+                    "candidate"
                 ],
-                "summary": "Get comments by post-ID",
+                "summary": "Get a candidate's timeline",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Post ID",
-                        "name": "postId",
-                        "in": "query",
+                        "description": "Candidate ID",
+                        "name": "id",
+                        "in": "path",
                         "required": true
                     }
                 ],
@@ -680,7 +733,7 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.Comment"
+                                "$ref": "#/definitions/models.CandidateActivity"
                             }
                         }
                     },
@@ -705,9 +758,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/amg/v1/comments/update-comment/{id}": {
-            "post": {
-                "description": "Update a comment by ID",
+        "/amg/v1/candidates/get-all-candidates": {
+            "get": {
+                "description": "Retrieves all candidates from the database, each with its latest activity. Candidates flagged as spam are left out unless include_spam=true; they can also be listed with get-candidates-by-status/spam. Staff only.",
                 "consumes": [
                     "application/json"
                 ],
@@ -715,43 +768,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Comment"
+                    "candidate"
                 ],
-                "summary": "Update a comment",
+                "summary": "Get all candidate",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Comment ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Comment data",
-                        "name": "comment",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.Comment"
-                        }
+                        "type": "boolean",
+                        "description": "Include candidates flagged as spam",
+                        "name": "include_spam",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Candidate"
                             }
                         }
                     },
@@ -767,9 +801,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/amg/v1/images/update-status": {
-            "post": {
-                "description": "Receives an array of image URLs and their styles, marks them as 'used' and saves their styles.",
+        "/amg/v1/candidates/get-candidate/{id}": {
+            "get": {
+                "description": "Retrieves a candidate by its ID. Staff only.",
                 "consumes": [
                     "application/json"
                 ],
@@ -777,26 +811,27 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "upload"
+                    "candidate"
                 ],
-                "summary": "Update status and style of multiple images",
+                "summary": "Get candidate by ID",
                 "parameters": [
                     {
-                        "description": "Array of images to update",
-                        "name": "images",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/uploaded_image.UpdateImagePayload"
-                            }
-                        }
+                        "type": "string",
+                        "description": "Candidate ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Candidate"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -804,8 +839,8 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -825,25 +860,25 @@ const docTemplate = `{
                 }
             }
         },
-        "/amg/v1/images/upload-image": {
-            "post": {
-                "description": "Uploads an image, saves it, and returns its public URL. Marks the image as 'pending'.",
+        "/amg/v1/candidates/get-candidates-by-status/{status}": {
+            "get": {
+                "description": "Retrieves candidates by their status. Staff only.",
                 "consumes": [
-                    "multipart/form-data"
+                    "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "upload"
+                    "candidate"
                 ],
-                "summary": "Upload an image for content",
+                "summary": "Get candidates by status",
                 "parameters": [
                     {
-                        "type": "file",
-                        "description": "Image file to upload",
-                        "name": "image",
-                        "in": "formData",
+                        "type": "string",
+                        "description": "Candidate Status",
+                        "name": "status",
+                        "in": "path",
                         "required": true
                     }
                 ],
@@ -851,21 +886,9 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "allOf": [
-                                    {
-                                        "type": "string"
-                                    },
-                                    {
-                                        "type": "object",
-                                        "properties": {
-                                            "url": {
-                                                "type": "string"
-                                            }
-                                        }
-                                    }
-                                ]
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Candidate"
                             }
                         }
                     },
@@ -890,9 +913,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/amg/v1/landing-page/get-content": {
+        "/amg/v1/candidates/get-possible-duplicates": {
             "get": {
-                "description": "Get the content of the landing page",
+                "description": "Lists the candidates whose form matched an existing candidate by phone number, or by parent name and child's date of birth, newest first, each with the candidates it matched that still exist. Staff only.",
                 "consumes": [
                     "application/json"
                 ],
@@ -900,28 +923,21 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "landing page"
+                    "candidate"
                 ],
-                "summary": "Get Landing Page Content",
+                "summary": "List candidates flagged as duplicates",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
-                    },
-                    "404": {
-                        "description": "message: Nội dung chưa được khởi tạo",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.CandidateDuplicates"
                             }
                         }
                     },
                     "500": {
-                        "description": "error: Lỗi máy chủ",
+                        "description": "Internal Server Error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -932,9 +948,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/amg/v1/landing-page/update-content": {
+        "/amg/v1/candidates/merge-candidate/{id}": {
             "post": {
-                "description": "Update the content of the landing page",
+                "description": "Folds duplicate_id into the candidate. Empty fields are filled from the duplicate and names and address keep the fuller version; the candidate takes the furthest admissions stage of the two. The duplicate's activity timeline and tour booking move over (if both have a tour, the duplicate's is cancelled), and the duplicate goes to the trash with merged_into set. A merge that failed part-way is completed by sending it again. Staff only.",
                 "consumes": [
                     "application/json"
                 ],
@@ -942,33 +958,36 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "landing page"
+                    "candidate"
                 ],
-                "summary": "Update Landing Page Content",
+                "summary": "Merge a duplicate into a candidate",
                 "parameters": [
                     {
-                        "description": "Object containing the new content for the landing page",
-                        "name": "content",
+                        "type": "string",
+                        "description": "ID of the candidate to keep",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Duplicate to merge",
+                        "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/models.MergeCandidatePayload"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "message: \"Nội dung landing page đã được cập nhật thành công",
+                        "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/models.Candidate"
                         }
                     },
                     "400": {
-                        "description": "error: Dữ liệu không hợp lệ",
+                        "description": "Bad Request",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -976,76 +995,17 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "500": {
-                        "description": "error: Lỗi máy chủ",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
                                 "type": "string"
                             }
                         }
-                    }
-                }
-            }
-        },
-        "/amg/v1/posts/create-post": {
-            "post": {
-                "description": "Creates a new post. The content should contain full URLs to images previously uploaded.",
-                "consumes": [
-                    "multipart/form-data"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "post"
-                ],
-                "summary": "Create a new post",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Post Title",
-                        "name": "title",
-                        "in": "formData",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Post Content",
-                        "name": "content",
-                        "in": "formData",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Post Category",
-                        "name": "category",
-                        "in": "formData",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Post Author",
-                        "name": "author",
-                        "in": "formData",
-                        "required": true
-                    },
-                    {
-                        "type": "file",
-                        "description": "Header Image",
-                        "name": "headerImage",
-                        "in": "formData"
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/models.Post"
-                        }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -1065,9 +1025,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/amg/v1/posts/delete-post/{id}": {
+        "/amg/v1/candidates/recovery-candidate/{id}": {
             "post": {
-                "description": "Deletes a post by its ID",
+                "description": "Restores a candidate from the trash to the status it had before deletion. Staff only.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1075,13 +1035,13 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "post"
+                    "candidate"
                 ],
-                "summary": "Delete a post",
+                "summary": "Recover a deleted candidate",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Post ID",
+                        "description": "Candidate ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -1106,40 +1066,14 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
                                 "type": "string"
                             }
                         }
-                    }
-                }
-            }
-        },
-        "/amg/v1/posts/get-all-posts": {
-            "get": {
-                "description": "Retrieves all posts from the database",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "post"
-                ],
-                "summary": "Get all posts",
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Post"
-                            }
-                        }
                     },
                     "500": {
                         "description": "Internal Server Error",
@@ -1153,9 +1087,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/amg/v1/posts/get-post/{id}": {
-            "get": {
-                "description": "Retrieves a post by its ID",
+        "/amg/v1/candidates/transition-candidate/{id}": {
+            "post": {
+                "description": "Moves a candidate through the admissions funnel: new → contacted → tour_scheduled → applied → offered → enrolled, or out of it as declined or lost (a reason is required). Stages may be skipped going forward, declined and lost candidates can be reopened as contacted, and spam can be released as new. Other moves are rejected with 409. The time each stage was entered is kept in stage_dates, and the move is added to the candidate's activity timeline. Closing a candidate as declined or lost cancels their booked tour and frees the place.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1163,23 +1097,32 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "post"
+                    "candidate"
                 ],
-                "summary": "Get a single post by ID with associated images",
+                "summary": "Move a candidate to another admissions stage",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Post ID",
+                        "description": "Candidate ID",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Target status and, for declined or lost, the reason",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CandidateTransitionPayload"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.PostDetailResponse"
+                            "$ref": "#/definitions/models.Candidate"
                         }
                     },
                     "400": {
@@ -1200,6 +1143,15 @@ const docTemplate = `{
                             }
                         }
                     },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -1212,9 +1164,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/amg/v1/posts/get-posts-by-category/{category}": {
-            "get": {
-                "description": "Retrieves posts by category",
+        "/amg/v1/candidates/update-candidate/{id}": {
+            "post": {
+                "description": "Updates a candidate's details by its ID: student_name, gender, dob, parent_name, address and phone. The phone and date of birth are validated and normalised as in create-candidate; invalid fields are listed in 'fields' of a 400 response. The status is changed with transition-candidate.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1222,31 +1174,34 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "post"
+                    "candidate"
                 ],
-                "summary": "Get posts by category",
+                "summary": "Update a candidate",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Post Category",
-                        "name": "category",
+                        "description": "Candidate ID",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "Filter by post-status (e.g., 'published', 'draft')",
-                        "name": "status",
-                        "in": "query"
+                        "description": "Candidate data to update",
+                        "name": "candidate",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Candidate"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Post"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
                             }
                         }
                     },
@@ -1259,6 +1214,15 @@ const docTemplate = `{
                             }
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -1271,9 +1235,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/amg/v1/posts/get-posts-by-status/{status}": {
+        "/amg/v1/captcha/get-challenge": {
             "get": {
-                "description": "Retrieves posts by status",
+                "description": "Issues a signed challenge that anonymous visitors must solve before create-comment and create-candidate. For kind 'pow' find a nonce such that sha256(challenge + \":\" + nonce) starts with 'difficulty' zero bits; for kind 'arithmetic' answer the question. Send the token and the nonce or answer in the X-Captcha-Token and X-Captcha-Solution headers. Each challenge can be used once and expires after 10 minutes.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1281,35 +1245,14 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "post"
-                ],
-                "summary": "Get posts by status",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Post Status",
-                        "name": "status",
-                        "in": "path",
-                        "required": true
-                    }
+                    "captcha"
                 ],
+                "summary": "Get a captcha challenge",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Post"
-                            }
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/models.CaptchaChallenge"
                         }
                     },
                     "500": {
@@ -1324,9 +1267,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/amg/v1/posts/get-single-post-by-category/{category}": {
-            "get": {
-                "description": "Retrieves a single post by category",
+        "/amg/v1/comments/create-comment": {
+            "post": {
+                "description": "Create a new comment for a post, or a reply to another comment with parentId. Logged-in users comment under the name on their account (or a neutral label if it has none), whatever authorName says. Replies of logged-in staff are marked as such. Depending on the moderation mode of the post's category the comment is published at once or created with status 'pending' until staff approve it. Comments flagged by the content filters (profanity, links, repetition, or a filled-in 'website' honeypot field) go to the moderation queue with status 'spam'.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1334,23 +1277,37 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "post"
+                    "Comment"
                 ],
-                "summary": "Get a single post by category",
+                "summary": "Create a new comment",
                 "parameters": [
+                    {
+                        "description": "Data to create a comment",
+                        "name": "comment",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateCommentPayload"
+                        }
+                    },
                     {
                         "type": "string",
-                        "description": "Post Category",
-                        "name": "category",
-                        "in": "path",
-                        "required": true
+                        "description": "Token from /captcha/get-challenge; not needed when logged in",
+                        "name": "X-Captcha-Token",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Nonce or answer solving the challenge",
+                        "name": "X-Captcha-Solution",
+                        "in": "header"
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/models.Post"
+                            "$ref": "#/definitions/models.Comment"
                         }
                     },
                     "400": {
@@ -1362,6 +1319,15 @@ const docTemplate = `{
                             }
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -1383,9 +1349,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/amg/v1/posts/recovery-post/{id}": {
+        "/amg/v1/comments/delete-comment/{id}": {
             "post": {
-                "description": "Recovers a post by its ID",
+                "description": "Move a comment to the trash by ID. Authors can delete their own comments within COMMENT.EDIT_WINDOW_MINUTES of posting them, staff any comment. It is purged permanently after the retention period. Its replies stay visible under an empty placeholder, and move up a level once it is purged.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1393,13 +1359,13 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "post"
+                    "Comment"
                 ],
-                "summary": "Recover a deleted post",
+                "summary": "Delete a comment",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Post ID",
+                        "description": "Comment ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -1424,6 +1390,33 @@ const docTemplate = `{
                             }
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -1436,40 +1429,40 @@ const docTemplate = `{
                 }
             }
         },
-        "/amg/v1/posts/update-post/{id}": {
-            "post": {
-                "description": "Updates a post by its ID",
+        "/amg/v1/comments/get-comment-history/{id}": {
+            "get": {
+                "description": "Lists the earlier versions of an edited comment, oldest first. Available to staff and to the comment's author.",
                 "consumes": [
-                    "multipart/form-data"
+                    "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "post"
+                    "Comment"
                 ],
-                "summary": "Update a post",
+                "summary": "Get the edit history of a comment",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Post ID",
+                        "description": "Comment ID",
                         "name": "id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "description": "Post data",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.Post"
-                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.CommentRevision"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -1477,8 +1470,26 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -1498,9 +1509,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/amg/v1/users/deactivate-user/{id}": {
-            "post": {
-                "description": "Deactivates a user account by setting isActive to false",
+        "/amg/v1/comments/get-comments-in-post": {
+            "get": {
+                "description": "Get all comments for a specific post with their replies. By default comments are listed flat in reading order with their depth; tree=true nests replies under the comment they answer. Only published comments are shown to readers, staff also see pending, rejected and spam ones. Deleted or hidden comments that still have replies are returned as empty placeholders. Each comment carries its reaction counts and, for logged-in users, their own reaction.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1508,21 +1519,45 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "Comment"
                 ],
-                "summary": "Deactivate a user",
+                "summary": "Get comments by post-ID",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "User ID",
-                        "name": "id",
-                        "in": "path",
+                        "description": "Post ID",
+                        "name": "postId",
+                        "in": "query",
                         "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Nest replies instead of listing them flat",
+                        "name": "tree",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.CommentView"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -1542,9 +1577,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/amg/v1/users/get-all-user": {
+        "/amg/v1/comments/get-reports/{id}": {
             "get": {
-                "description": "Retrieves all users from the database",
+                "description": "Lists why readers reported a comment, oldest first. Staff only.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1552,16 +1587,34 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "Comment"
+                ],
+                "summary": "List the reports on a comment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Comment ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Get all user",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.User"
+                                "$ref": "#/definitions/models.CommentReport"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
                             }
                         }
                     },
@@ -1577,9 +1630,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/amg/v1/users/get-user/{id}": {
-            "get": {
-                "description": "Retrieves a user by their ID",
+        "/amg/v1/comments/moderate/{id}": {
+            "post": {
+                "description": "Sets the moderation status of a comment. Approved comments are published; rejected and spam ones are hidden from readers. Reader reports count from zero again afterwards. Staff only.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1587,23 +1640,35 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "Comment"
                 ],
-                "summary": "Get user by ID",
+                "summary": "Approve, reject or flag a comment as spam",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "User ID",
+                        "description": "Comment ID",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Moderation action: approve, reject or spam",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ModerateCommentPayload"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.User"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     },
                     "400": {
@@ -1636,9 +1701,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/amg/v1/users/reactivate-user/{id}": {
-            "post": {
-                "description": "Reactivates a previously deactivated user account",
+        "/amg/v1/comments/moderation-queue": {
+            "get": {
+                "description": "Lists comments across all posts, oldest first, with the title of their post. Staff only.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1646,21 +1711,35 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "Comment"
                 ],
-                "summary": "Reactivate a user",
+                "summary": "List comments awaiting moderation",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "User ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Comment status to list: pending (default), rejected or spam",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of comments (default 50, max 200)",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ModerationQueueItem"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -1680,9 +1759,40 @@ const docTemplate = `{
                 }
             }
         },
-        "/amg/v1/users/update-user/{id}": {
+        "/amg/v1/comments/moderation-settings": {
+            "get": {
+                "description": "Returns the default moderation mode and the per-category overrides. An override with an empty category applies to all categories. Staff only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Comment"
+                ],
+                "summary": "Get the comment moderation settings",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
             "post": {
-                "description": "Updates user information based on the provided ID",
+                "description": "Sets 'pre' (hold comments for approval) or 'post' (publish at once) moderation for a category, or for all categories when category is empty. Staff only.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1690,24 +1800,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "Comment"
                 ],
-                "summary": "Update user information",
+                "summary": "Set the moderation mode of a category",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "User ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "User data to update",
+                        "description": "Category and mode",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.User"
+                            "$ref": "#/definitions/models.CommentModerationSetting"
                         }
                     }
                 ],
@@ -1741,182 +1844,4231 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "auth.LoginRequest": {
-            "type": "object",
-            "properties": {
-                "password": {
-                    "type": "string"
-                },
-                "username": {
-                    "type": "string"
-                }
-            }
         },
-        "auth.RegisterRequest": {
-            "type": "object",
-            "required": [
-                "confirm_password",
-                "name",
-                "password",
-                "username"
-            ],
-            "properties": {
-                "confirm_password": {
-                    "type": "string",
+        "/amg/v1/comments/react/{id}": {
+            "post": {
+                "description": "Sets the logged-in user's reaction to a published comment: like, heart or thanks. A user has one reaction per comment; reacting again replaces it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Comment"
+                ],
+                "summary": "React to a comment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Comment ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Reaction type",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ReactCommentPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/amg/v1/comments/remove-reaction/{id}": {
+            "post": {
+                "description": "Withdraws the logged-in user's reaction to a comment.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Comment"
+                ],
+                "summary": "Remove a reaction from a comment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Comment ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/amg/v1/comments/report/{id}": {
+            "post": {
+                "description": "Flags a published comment as inappropriate. Each reader can report a comment once; anonymous readers are told apart by IP and user agent and must solve a captcha. Once COMMENT.REPORT_THRESHOLD signed-in readers have reported it the comment is hidden and goes back to the moderation queue as pending; anonymous reports are kept for moderators but do not hide comments.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Comment"
+                ],
+                "summary": "Report a comment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Comment ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Why the comment is inappropriate",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ReportCommentPayload"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Token from /captcha/get-challenge; not needed when logged in",
+                        "name": "X-Captcha-Token",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Nonce or answer solving the challenge",
+                        "name": "X-Captcha-Solution",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/amg/v1/comments/update-comment/{id}": {
+            "post": {
+                "description": "Replace the content of a comment. Authors can edit their own comments within COMMENT.EDIT_WINDOW_MINUTES of posting them, staff any comment. The previous content is kept in the comment's history and the comment is marked as edited. Edits by anyone but staff are screened again and may send the comment back to moderation.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Comment"
+                ],
+                "summary": "Update a comment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Comment ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New content",
+                        "name": "comment",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateCommentPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Comment"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/amg/v1/images/update-status": {
+            "post": {
+                "description": "Receives an array of image URLs and their styles, marks them as 'used' and saves their styles.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "upload"
+                ],
+                "summary": "Update status and style of multiple images",
+                "parameters": [
+                    {
+                        "description": "Array of images to update",
+                        "name": "images",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/uploaded_image.UpdateImagePayload"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/amg/v1/images/upload-image": {
+            "post": {
+                "description": "Uploads an image, saves it, and returns its public URL. Marks the image as 'pending'.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "upload"
+                ],
+                "summary": "Upload an image for content",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "Image file to upload",
+                        "name": "image",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "allOf": [
+                                    {
+                                        "type": "string"
+                                    },
+                                    {
+                                        "type": "object",
+                                        "properties": {
+                                            "url": {
+                                                "type": "string"
+                                            }
+                                        }
+                                    }
+                                ]
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/amg/v1/integrity/get-report": {
+            "get": {
+                "description": "Returns the last report of missing or untracked /uploads files and internal links to deleted posts, as produced by the nightly check",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "integrity"
+                ],
+                "summary": "Get the latest content integrity report",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only list issues of one type: missing_file, missing_record, pending_record, deleted_post_link or missing_post_link",
+                        "name": "type",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.IntegrityReport"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/amg/v1/integrity/run-check": {
+            "post": {
+                "description": "Scans posts and the landing page immediately instead of waiting for the nightly job, and returns the new report",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "integrity"
+                ],
+                "summary": "Run the content integrity check now",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.IntegrityReport"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/amg/v1/landing-page/get-content": {
+            "get": {
+                "description": "Get the content of the landing page",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "landing page"
+                ],
+                "summary": "Get Landing Page Content",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "message: Nội dung chưa được khởi tạo",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "error: Lỗi máy chủ",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/amg/v1/landing-page/update-content": {
+            "post": {
+                "description": "Update the content of the landing page",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "landing page"
+                ],
+                "summary": "Update Landing Page Content",
+                "parameters": [
+                    {
+                        "description": "Object containing the new content for the landing page",
+                        "name": "content",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message: \"Nội dung landing page đã được cập nhật thành công",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "error: Dữ liệu không hợp lệ",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "error: Lỗi máy chủ",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/amg/v1/posts/bulk-action": {
+            "post": {
+                "description": "Applies one action to a list of posts and reports the outcome for each of them. Actions: set_status (status), set_category (category), add_tags (tags), remove_tags (tags), delete, restore. Staff only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "post"
+                ],
+                "summary": "Apply an action to several posts",
+                "parameters": [
+                    {
+                        "description": "Post IDs and action",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.BulkPostActionPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.BulkPostActionResult"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/amg/v1/posts/create-post": {
+            "post": {
+                "description": "Creates a new post. The content should contain full URLs to images previously uploaded; pasted base64 images are extracted into /uploads. Staff only.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "post"
+                ],
+                "summary": "Create a new post",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Post Title",
+                        "name": "title",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Post Content (HTML, or Markdown source when content_format is 'markdown')",
+                        "name": "content",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Content format: 'html' (default) or 'markdown'",
+                        "name": "content_format",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Post Category",
+                        "name": "category",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Post Author",
+                        "name": "author",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Post Tags (repeated or comma separated)",
+                        "name": "tags",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Post Status: 'active' (default) or 'draft'",
+                        "name": "status",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "IDs of uploaded attachments (repeated or comma separated)",
+                        "name": "attachment_ids",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Who may read the post: 'public' (default), 'members' or 'classes'",
+                        "name": "visibility",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Classes allowed to read the post when visibility is 'classes' (repeated or comma separated)",
+                        "name": "visible_to_classes",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Excerpt, generated from the content when empty",
+                        "name": "excerpt",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Open Graph title override",
+                        "name": "og_title",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Open Graph description override",
+                        "name": "og_description",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Open Graph image override",
+                        "name": "og_image",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "file",
+                        "description": "Header Image",
+                        "name": "headerImage",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Post"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/amg/v1/posts/create-preview-link/{id}": {
+            "post": {
+                "description": "Issues a signed, expiring token that lets anyone holding it read the post, whatever its status, without logging in. Staff only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "post"
+                ],
+                "summary": "Create a preview link for a post",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Post ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Validity in hours (default 72, max 336)",
+                        "name": "body",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/models.CreatePreviewLinkPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.PreviewLinkResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/amg/v1/posts/delete-post/{id}": {
+            "post": {
+                "description": "Moves a post to the trash. It is purged permanently after the retention period. Staff only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "post"
+                ],
+                "summary": "Delete a post",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Post ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/amg/v1/posts/get-all-posts": {
+            "get": {
+                "description": "Retrieves all posts from the database. Visitors who are not staff only get active posts.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "post"
+                ],
+                "summary": "Get all posts",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Leave out the content (for list views)",
+                        "name": "light",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Post"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/amg/v1/posts/get-featured-posts": {
+            "get": {
+                "description": "Retrieves active featured posts, optionally limited to one category. Pinned posts come first.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "post"
+                ],
+                "summary": "Get featured posts",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Post Category",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Leave out the content (for list views)",
+                        "name": "light",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Post"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/amg/v1/posts/get-popular-posts": {
+            "get": {
+                "description": "Retrieves the public active posts with the most views over the last N days",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "post"
+                ],
+                "summary": "Get the most read posts",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Number of days to look back (default 7, max 90)",
+                        "name": "days",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of posts (default 5, max 20)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.PopularPost"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/amg/v1/posts/get-post/{id}": {
+            "get": {
+                "description": "Retrieves a post by its ID and counts a view (once per visitor per day, bots excluded). Posts that are not active are only returned to staff; members and class restricted posts need a matching logged-in user.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "post"
+                ],
+                "summary": "Get a single post by ID with associated images",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Post ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.PostDetailResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/amg/v1/posts/get-posts-by-category/{category}": {
+            "get": {
+                "description": "Retrieves posts by category, pinned posts first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "post"
+                ],
+                "summary": "Get posts by category",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Post Category",
+                        "name": "category",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by post-status (e.g., 'active', 'draft'); ignored for visitors who are not staff",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Leave out the content (for list views)",
+                        "name": "light",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Post"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/amg/v1/posts/get-posts-by-status/{status}": {
+            "get": {
+                "description": "Retrieves posts by status. Only active posts are returned to visitors who are not staff.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "post"
+                ],
+                "summary": "Get posts by status",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Post Status",
+                        "name": "status",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Leave out the content (for list views)",
+                        "name": "light",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Post"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/amg/v1/posts/get-related-posts/{id}": {
+            "get": {
+                "description": "Retrieves \"you may also like\" suggestions for a post, based on shared category, tags and text similarity. Only public posts are suggested. Suggestions are computed in the background, so a new or just-edited post may have none for a short while.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "post"
+                ],
+                "summary": "Get related posts",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Post ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of posts (default 4, max 8)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Post"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/amg/v1/posts/get-single-post-by-category/{category}": {
+            "get": {
+                "description": "Retrieves the top pinned post of a category, otherwise the newest featured post, otherwise the newest active post",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "post"
+                ],
+                "summary": "Get a single post by category",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Post Category",
+                        "name": "category",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Post"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/amg/v1/posts/import-wordpress": {
+            "post": {
+                "description": "Imports the posts of a WordPress WXR export. Media under wp-content/uploads is copied into /uploads (from WORDPRESS.MEDIA_DIR, or downloaded from the exported site's own host) and image URLs are rewritten. Posts imported before are skipped, so the import can be re-run.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "post"
+                ],
+                "summary": "Import posts from a WordPress export",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "WXR export (.xml)",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "JSON object mapping WordPress category slugs to categories; unmapped categories fall back to default_category",
+                        "name": "category_map",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Category for posts without a (mapped) category",
+                        "name": "default_category",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.WordPressImportResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/amg/v1/posts/pin-post/{id}": {
+            "post": {
+                "description": "Pins a post. Without an explicit order the post is placed after the posts already pinned in its category. An optional pinned_until makes the pin expire. Staff only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "post"
+                ],
+                "summary": "Pin a post to the top of its category",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Post ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Pin options",
+                        "name": "body",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/models.PinPostPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/amg/v1/posts/preview/{id}": {
+            "get": {
+                "description": "Retrieves a post with its images through a preview link, whatever its status. Attachment URLs carry the preview token so that they download while the post is unpublished. No login required.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "post"
+                ],
+                "summary": "Preview a post",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Post ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Preview token",
+                        "name": "token",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.PostDetailResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/amg/v1/posts/recovery-post/{id}": {
+            "post": {
+                "description": "Restores a post from the trash to the status it had before deletion. Staff only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "post"
+                ],
+                "summary": "Recover a deleted post",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Post ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/amg/v1/posts/regenerate-summaries": {
+            "post": {
+                "description": "Recomputes the excerpt, word count, reading time and Open Graph metadata of every post. Staff only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "post"
+                ],
+                "summary": "Regenerate post summaries",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/amg/v1/posts/reorder-pinned-posts/{category}": {
+            "post": {
+                "description": "Sets the pin order of the given posts to their position in the list. Every post must be pinned and belong to the category. Staff only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "post"
+                ],
+                "summary": "Reorder the pinned posts of a category",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Post Category",
+                        "name": "category",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Pinned post IDs in display order",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ReorderPinnedPostsPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/amg/v1/posts/revoke-preview-links/{id}": {
+            "post": {
+                "description": "Invalidates every preview link issued so far for the post. Staff only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "post"
+                ],
+                "summary": "Revoke the preview links of a post",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Post ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/amg/v1/posts/set-featured/{id}": {
+            "post": {
+                "description": "Sets the featured flag of a post. Staff only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "post"
+                ],
+                "summary": "Mark or unmark a post as featured",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Post ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Featured flag",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.FeaturePostPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/amg/v1/posts/unpin-post/{id}": {
+            "post": {
+                "description": "Removes the pin from a post. Staff only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "post"
+                ],
+                "summary": "Unpin a post",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Post ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/amg/v1/posts/update-post/{id}": {
+            "post": {
+                "description": "Updates a post by its ID. Attachments in attachment_ids that do not exist or belong to another post are not linked and are listed in skipped_attachment_ids. Staff only.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "post"
+                ],
+                "summary": "Update a post",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Post ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Post data",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Post"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/amg/v1/tours/book": {
+            "post": {
+                "description": "Reserves a place on a tour slot for a candidate without a booking, e.g. after a phone call. The candidate moves to tour_scheduled where the admissions funnel allows it, and the booking is added to their timeline. Staff only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tour"
+                ],
+                "summary": "Book a tour for a candidate",
+                "parameters": [
+                    {
+                        "description": "Candidate and slot",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.BookTourPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.TourBooking"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/amg/v1/tours/cancel-booking/{id}": {
+            "post": {
+                "description": "Cancels a booking and frees its place. The cancellation and its reason are added to the candidate's timeline. Staff only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tour"
+                ],
+                "summary": "Cancel a tour booking",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Booking ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Reason",
+                        "name": "body",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/models.CancelTourPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.TourBooking"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/amg/v1/tours/create-rule": {
+            "post": {
+                "description": "Defines a weekly tour at a campus: weekday (0 = Sunday), start time (HH:MM, in TOUR.TIMEZONE), duration in minutes (default 60) and how many families can join. Staff only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tour"
+                ],
+                "summary": "Create a recurring tour slot",
+                "parameters": [
+                    {
+                        "description": "Rule",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.TourSlotRulePayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.TourSlotRule"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/amg/v1/tours/get-available-slots": {
+            "get": {
+                "description": "Lists the upcoming campus tour slots that still have free places, soonest first. The public candidate form offers these; pass the chosen slot's id as tour_slot_id to create-candidate.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tour"
+                ],
+                "summary": "List tour slots with free places",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only slots of this campus",
+                        "name": "campus",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "How many days ahead (default 14, max 60)",
+                        "name": "days",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.TourSlot"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/amg/v1/tours/get-bookings": {
+            "get": {
+                "description": "Lists the bookings of tours in a period, soonest first. Staff only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tour"
+                ],
+                "summary": "List tour bookings",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only bookings of this campus",
+                        "name": "campus",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "First day (YYYY-MM-DD, default today)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last day (YYYY-MM-DD, default 14 days after from)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Include cancelled bookings",
+                        "name": "include_cancelled",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.TourBooking"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/amg/v1/tours/get-rules": {
+            "get": {
+                "description": "Lists the weekly tour slots of every campus, or of one. Staff only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tour"
+                ],
+                "summary": "List recurring tour slots",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only rules of this campus",
+                        "name": "campus",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.TourSlotRule"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/amg/v1/tours/reschedule-booking/{id}": {
+            "post": {
+                "description": "Moves a booking to another slot with a free place; if that slot is full the booking stays as it was. The change is added to the candidate's timeline. Staff only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tour"
+                ],
+                "summary": "Move a tour booking to another slot",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Booking ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New slot",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.RescheduleTourPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.TourBooking"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/amg/v1/tours/update-rule/{id}": {
+            "post": {
+                "description": "Replaces a weekly tour slot, or deactivates it with active=false. Upcoming tours that already have bookings keep their time and take the new capacity. Staff only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tour"
+                ],
+                "summary": "Update a recurring tour slot",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Rule ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Rule",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.TourSlotRulePayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.TourSlotRule"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/amg/v1/trash/get-trash": {
+            "get": {
+                "description": "Lists soft-deleted posts, candidates and comments, most recently deleted first, with the date each one will be purged",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trash"
+                ],
+                "summary": "List the trash",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only list one type: post, candidate or comment",
+                        "name": "type",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.TrashItem"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/amg/v1/trash/purge/{type}/{id}": {
+            "post": {
+                "description": "Permanently deletes a soft-deleted item without waiting for the retention period. Images and attachments of a post are released to the cleanup jobs.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trash"
+                ],
+                "summary": "Permanently delete an item from the trash",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Item type: post, candidate or comment",
+                        "name": "type",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Item ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/amg/v1/trash/restore/{type}/{id}": {
+            "post": {
+                "description": "Restores a soft-deleted item to the status it had before deletion",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trash"
+                ],
+                "summary": "Restore an item from the trash",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Item type: post, candidate or comment",
+                        "name": "type",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Item ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/amg/v1/users/deactivate-user/{id}": {
+            "post": {
+                "description": "Deactivates a user account by setting isActive to false",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Deactivate a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/amg/v1/users/get-all-user": {
+            "get": {
+                "description": "Retrieves all users from the database",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Get all user",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.User"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/amg/v1/users/get-user/{id}": {
+            "get": {
+                "description": "Retrieves a user by their ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Get user by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/amg/v1/users/reactivate-user/{id}": {
+            "post": {
+                "description": "Reactivates a previously deactivated user account",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Reactivate a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/amg/v1/users/set-classes/{id}": {
+            "post": {
+                "description": "Replaces the classes a parent's children are in. Posts with visibility \"classes\" are shown to parents with one of the post's classes. An empty list removes them all. Staff only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Set the classes of a parent's children",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Classes",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SetUserClassesPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/amg/v1/users/update-user/{id}": {
+            "post": {
+                "description": "Updates user information based on the provided ID. Users can update their own account and staff any account. The role and classes are not changed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Update user information",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "User data to update",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "auth.LoginRequest": {
+            "type": "object",
+            "properties": {
+                "password": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "auth.RegisterRequest": {
+            "type": "object",
+            "required": [
+                "confirm_password",
+                "name",
+                "password",
+                "username"
+            ],
+            "properties": {
+                "confirm_password": {
+                    "type": "string",
+                    "maxLength": 20,
+                    "minLength": 6
+                },
+                "name": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string",
                     "maxLength": 20,
                     "minLength": 6
                 },
-                "name": {
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.AddCandidateActivityPayload": {
+            "type": "object",
+            "properties": {
+                "channel": {
+                    "type": "string"
+                },
+                "content": {
+                    "type": "string"
+                },
+                "next_follow_up": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Attachment": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "filename": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "mime_type": {
+                    "type": "string"
+                },
+                "original_name": {
+                    "type": "string"
+                },
+                "post_id": {
+                    "type": "string"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/models.AttachmentStatus"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.AttachmentStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "used"
+            ],
+            "x-enum-varnames": [
+                "AttachmentStatusPending",
+                "AttachmentStatusUsed"
+            ]
+        },
+        "models.BookTourPayload": {
+            "type": "object",
+            "properties": {
+                "candidate_id": {
+                    "type": "string"
+                },
+                "slot_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.BulkPostActionPayload": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "category": {
+                    "type": "string"
+                },
+                "post_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "status": {
+                    "type": "string"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.BulkPostActionResult": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.CancelTourPayload": {
+            "type": "object",
+            "properties": {
+                "reason": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Candidate": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "age_group": {
+                    "description": "The child's kindergarten group, worked out when the candidate is saved\nand again by the daily candidate job as school years change",
+                    "type": "string"
+                },
+                "birth_date": {
+                    "description": "DateOfBirth parsed, with DateOfBirth kept as YYYY-MM-DD and Phone in E.164",
+                    "type": "string"
+                },
+                "closed_reason": {
+                    "description": "Why the candidate was declined or lost",
+                    "type": "string"
+                },
+                "create_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "description": "Set while in the trash; PreviousStatus is restored on recovery",
+                    "type": "string"
+                },
+                "dob": {
+                    "type": "string"
+                },
+                "gender": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_activity": {
+                    "description": "The newest entry of the candidate's timeline",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.CandidateActivitySummary"
+                        }
+                    ]
+                },
+                "merged_into": {
+                    "description": "Set on a duplicate merged into another candidate; it is kept in the trash",
+                    "type": "string"
+                },
+                "parent_name": {
+                    "type": "string"
+                },
+                "phone": {
+                    "type": "string"
+                },
+                "possible_duplicates": {
+                    "description": "Candidates this one likely duplicates, until staff merge or dismiss them",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "spam_reasons": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "spam_score": {
+                    "description": "What the content filters found when the form was submitted",
+                    "type": "number"
+                },
+                "stage_dates": {
+                    "description": "When the candidate entered each stage, keyed by status",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "status": {
+                    "type": "string"
+                },
+                "student_name": {
+                    "type": "string"
+                },
+                "tour": {
+                    "description": "The upcoming campus tour, if one is booked",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.CandidateTour"
+                        }
+                    ]
+                },
+                "update_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CandidateActivity": {
+            "type": "object",
+            "properties": {
+                "author_id": {
+                    "type": "string"
+                },
+                "author_name": {
+                    "type": "string"
+                },
+                "candidate_id": {
+                    "type": "string"
+                },
+                "channel": {
+                    "description": "Channel is how the family was reached, e.g. phone, zalo, email, in person",
+                    "type": "string"
+                },
+                "content": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "from_status": {
+                    "description": "Set for status changes",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "next_follow_up": {
+                    "description": "When staff should get back to the family",
+                    "type": "string"
+                },
+                "to_status": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CandidateActivitySummary": {
+            "type": "object",
+            "properties": {
+                "at": {
+                    "type": "string"
+                },
+                "author_name": {
+                    "type": "string"
+                },
+                "channel": {
+                    "type": "string"
+                },
+                "next_follow_up": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CandidateDuplicates": {
+            "type": "object",
+            "properties": {
+                "candidate": {
+                    "$ref": "#/definitions/models.Candidate"
+                },
+                "matches": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Candidate"
+                    }
+                }
+            }
+        },
+        "models.CandidateTour": {
+            "type": "object",
+            "properties": {
+                "booking_id": {
+                    "type": "string"
+                },
+                "campus": {
+                    "type": "string"
+                },
+                "start_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CandidateTransitionPayload": {
+            "type": "object",
+            "properties": {
+                "reason": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CaptchaChallenge": {
+            "type": "object",
+            "properties": {
+                "challenge": {
+                    "type": "string"
+                },
+                "difficulty": {
+                    "type": "integer"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "question": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Comment": {
+            "type": "object",
+            "properties": {
+                "_id": {
+                    "type": "string"
+                },
+                "authorId": {
+                    "type": "string"
+                },
+                "authorName": {
+                    "type": "string"
+                },
+                "authorRole": {
+                    "type": "string"
+                },
+                "content": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "deletedAt": {
+                    "description": "Set while in the trash; PreviousStatus is restored on recovery",
+                    "type": "string"
+                },
+                "depth": {
+                    "type": "integer"
+                },
+                "editedAt": {
+                    "description": "Set when the author or staff changed the content; earlier versions are\nkept as CommentRevision",
+                    "type": "string"
+                },
+                "memberReportCount": {
+                    "type": "integer"
+                },
+                "moderatedAt": {
+                    "description": "Set when staff approve, reject or flag the comment as spam",
+                    "type": "string"
+                },
+                "moderatedBy": {
+                    "type": "string"
+                },
+                "parentId": {
+                    "description": "Replies point at the comment they answer; Depth is 0 for top-level comments",
+                    "type": "string"
+                },
+                "postId": {
+                    "type": "string"
+                },
+                "reportCount": {
+                    "description": "Number of readers who reported the comment since staff last approved it,\nand how many of them were signed in. Only the latter can hide it.",
+                    "type": "integer"
+                },
+                "spamReasons": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "spamScore": {
+                    "description": "What the content filters found when the comment was submitted",
+                    "type": "number"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CommentModerationSetting": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "type": "string"
+                },
+                "mode": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CommentReport": {
+            "type": "object",
+            "properties": {
+                "_id": {
+                    "type": "string"
+                },
+                "commentId": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "reporter": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CommentRevision": {
+            "type": "object",
+            "properties": {
+                "_id": {
+                    "type": "string"
+                },
+                "commentId": {
+                    "type": "string"
+                },
+                "content": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "editedBy": {
+                    "description": "EditedBy is the user whose edit replaced this version",
+                    "type": "string"
+                }
+            }
+        },
+        "models.CommentView": {
+            "type": "object",
+            "properties": {
+                "_id": {
+                    "type": "string"
+                },
+                "authorId": {
+                    "type": "string"
+                },
+                "authorName": {
+                    "type": "string"
+                },
+                "authorRole": {
+                    "type": "string"
+                },
+                "content": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "deleted": {
+                    "type": "boolean"
+                },
+                "deletedAt": {
+                    "description": "Set while in the trash; PreviousStatus is restored on recovery",
+                    "type": "string"
+                },
+                "depth": {
+                    "type": "integer"
+                },
+                "edited": {
+                    "type": "boolean"
+                },
+                "editedAt": {
+                    "description": "Set when the author or staff changed the content; earlier versions are\nkept as CommentRevision",
+                    "type": "string"
+                },
+                "isStaff": {
+                    "type": "boolean"
+                },
+                "memberReportCount": {
+                    "type": "integer"
+                },
+                "moderatedAt": {
+                    "description": "Set when staff approve, reject or flag the comment as spam",
+                    "type": "string"
+                },
+                "moderatedBy": {
+                    "type": "string"
+                },
+                "myReaction": {
+                    "type": "string"
+                },
+                "parentId": {
+                    "description": "Replies point at the comment they answer; Depth is 0 for top-level comments",
+                    "type": "string"
+                },
+                "postId": {
+                    "type": "string"
+                },
+                "reactions": {
+                    "description": "Reactions counts the reactions by type; MyReaction is the requester's own",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "replies": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CommentView"
+                    }
+                },
+                "reportCount": {
+                    "description": "Number of readers who reported the comment since staff last approved it,\nand how many of them were signed in. Only the latter can hide it.",
+                    "type": "integer"
+                },
+                "spamReasons": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "spamScore": {
+                    "description": "What the content filters found when the comment was submitted",
+                    "type": "number"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CreateCommentPayload": {
+            "type": "object",
+            "properties": {
+                "authorName": {
+                    "type": "string"
+                },
+                "content": {
+                    "type": "string"
+                },
+                "parentId": {
+                    "type": "string"
+                },
+                "postId": {
+                    "type": "string"
+                },
+                "website": {
+                    "description": "Honeypot; hidden from people, so anything in it marks the comment as spam",
+                    "type": "string"
+                }
+            }
+        },
+        "models.CreatePreviewLinkPayload": {
+            "type": "object",
+            "properties": {
+                "expires_in_hours": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.FeaturePostPayload": {
+            "type": "object",
+            "properties": {
+                "featured": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.ImageStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "used"
+            ],
+            "x-enum-varnames": [
+                "ImageStatusPending",
+                "ImageStatusUsed"
+            ]
+        },
+        "models.IntegrityIssue": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string"
+                },
+                "source": {
+                    "type": "string"
+                },
+                "source_id": {
+                    "type": "string"
+                },
+                "source_title": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.IntegrityReport": {
+            "type": "object",
+            "properties": {
+                "checked_at": {
+                    "type": "string"
+                },
+                "counts": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "issues": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.IntegrityIssue"
+                    }
+                },
+                "posts_scanned": {
+                    "type": "integer"
+                },
+                "references_checked": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.MergeCandidatePayload": {
+            "type": "object",
+            "properties": {
+                "duplicate_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ModerateCommentPayload": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "description": "Action is one of approve, reject, spam",
+                    "type": "string"
+                }
+            }
+        },
+        "models.ModerationQueueItem": {
+            "type": "object",
+            "properties": {
+                "_id": {
+                    "type": "string"
+                },
+                "authorId": {
+                    "type": "string"
+                },
+                "authorName": {
+                    "type": "string"
+                },
+                "authorRole": {
+                    "type": "string"
+                },
+                "content": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "deletedAt": {
+                    "description": "Set while in the trash; PreviousStatus is restored on recovery",
+                    "type": "string"
+                },
+                "depth": {
+                    "type": "integer"
+                },
+                "editedAt": {
+                    "description": "Set when the author or staff changed the content; earlier versions are\nkept as CommentRevision",
+                    "type": "string"
+                },
+                "memberReportCount": {
+                    "type": "integer"
+                },
+                "moderatedAt": {
+                    "description": "Set when staff approve, reject or flag the comment as spam",
+                    "type": "string"
+                },
+                "moderatedBy": {
+                    "type": "string"
+                },
+                "parentId": {
+                    "description": "Replies point at the comment they answer; Depth is 0 for top-level comments",
+                    "type": "string"
+                },
+                "postCategory": {
+                    "type": "string"
+                },
+                "postId": {
+                    "type": "string"
+                },
+                "postTitle": {
+                    "type": "string"
+                },
+                "reportCount": {
+                    "description": "Number of readers who reported the comment since staff last approved it,\nand how many of them were signed in. Only the latter can hide it.",
+                    "type": "integer"
+                },
+                "spamReasons": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "spamScore": {
+                    "description": "What the content filters found when the comment was submitted",
+                    "type": "number"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.PinPostPayload": {
+            "type": "object",
+            "properties": {
+                "order": {
+                    "type": "integer"
+                },
+                "pinned_until": {
                     "type": "string"
+                }
+            }
+        },
+        "models.PopularPost": {
+            "type": "object",
+            "properties": {
+                "post": {
+                    "$ref": "#/definitions/models.Post"
                 },
-                "password": {
-                    "type": "string",
-                    "maxLength": 20,
-                    "minLength": 6
-                },
-                "username": {
-                    "type": "string"
+                "views": {
+                    "type": "integer"
                 }
             }
         },
-        "models.Candidate": {
+        "models.Post": {
             "type": "object",
             "properties": {
-                "address": {
+                "author": {
+                    "type": "string"
+                },
+                "category": {
+                    "type": "string"
+                },
+                "comment_count": {
+                    "description": "Published comments, kept up to date by service.RefreshPostCommentStats",
+                    "type": "integer"
+                },
+                "content": {
+                    "type": "string"
+                },
+                "content_format": {
+                    "description": "ContentFormat is \"html\" or \"markdown\". Markdown posts keep the editor's\nsource in ContentSource and the rendered, sanitised HTML in Content.",
+                    "type": "string"
+                },
+                "content_source": {
                     "type": "string"
                 },
                 "create_at": {
                     "type": "string"
                 },
-                "dob": {
+                "deleted_at": {
+                    "description": "Set while the post is in the trash; PreviousStatus is restored on recovery",
                     "type": "string"
                 },
-                "gender": {
+                "excerpt": {
+                    "description": "Generated on save from the content, unless overridden by an editor",
+                    "type": "string"
+                },
+                "excerpt_override": {
+                    "type": "string"
+                },
+                "header_image": {
                     "type": "string"
                 },
                 "id": {
                     "type": "string"
                 },
-                "parent_name": {
+                "is_featured": {
+                    "type": "boolean"
+                },
+                "is_pinned": {
+                    "type": "boolean"
+                },
+                "last_comment_at": {
                     "type": "string"
                 },
-                "phone": {
+                "legacy_id": {
+                    "description": "Identifies the post on the site it was imported from, e.g. \"wordpress:\u003cguid\u003e\"",
+                    "type": "string"
+                },
+                "meta": {
+                    "$ref": "#/definitions/models.PostMeta"
+                },
+                "meta_overrides": {
+                    "$ref": "#/definitions/models.PostMeta"
+                },
+                "pin_order": {
+                    "type": "integer"
+                },
+                "pinned_until": {
                     "type": "string"
                 },
+                "reading_time": {
+                    "type": "integer"
+                },
                 "status": {
                     "type": "string"
                 },
-                "student_name": {
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "title": {
                     "type": "string"
                 },
                 "update_at": {
                     "type": "string"
+                },
+                "view_count": {
+                    "type": "integer"
+                },
+                "visibility": {
+                    "description": "Who may read the post; see the Visibility* constants. Staff read everything.",
+                    "type": "string"
+                },
+                "visible_to_classes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "word_count": {
+                    "type": "integer"
                 }
             }
         },
-        "models.Comment": {
+        "models.PostDetailResponse": {
             "type": "object",
             "properties": {
-                "_id": {
+                "attachments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Attachment"
+                    }
+                },
+                "images": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.UploadedImage"
+                    }
+                },
+                "post": {
+                    "$ref": "#/definitions/models.Post"
+                }
+            }
+        },
+        "models.PostMeta": {
+            "type": "object",
+            "properties": {
+                "description": {
                     "type": "string"
                 },
-                "authorId": {
+                "image": {
                     "type": "string"
                 },
-                "authorName": {
+                "title": {
                     "type": "string"
                 },
-                "content": {
+                "twitter_card": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.PreviewLinkResponse": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
                     "type": "string"
                 },
-                "createdAt": {
+                "token": {
                     "type": "string"
                 },
-                "postId": {
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ReactCommentPayload": {
+            "type": "object",
+            "properties": {
+                "type": {
+                    "description": "Type is one of like, heart, thanks",
+                    "type": "string"
+                }
+            }
+        },
+        "models.ReorderPinnedPostsPayload": {
+            "type": "object",
+            "properties": {
+                "post_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.ReportCommentPayload": {
+            "type": "object",
+            "properties": {
+                "reason": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.RescheduleTourPayload": {
+            "type": "object",
+            "properties": {
+                "slot_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.SetUserClassesPayload": {
+            "type": "object",
+            "properties": {
+                "classes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.TourBooking": {
+            "type": "object",
+            "properties": {
+                "campus": {
+                    "type": "string"
+                },
+                "cancel_reason": {
+                    "type": "string"
+                },
+                "candidate_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "slot_id": {
+                    "type": "string"
+                },
+                "start_at": {
                     "type": "string"
                 },
                 "status": {
                     "type": "string"
                 },
-                "updatedAt": {
+                "updated_at": {
                     "type": "string"
                 }
             }
         },
-        "models.CreateCommentPayload": {
+        "models.TourSlot": {
             "type": "object",
             "properties": {
-                "authorId": {
+                "booked": {
+                    "type": "integer"
+                },
+                "campus": {
                     "type": "string"
                 },
-                "authorName": {
+                "capacity": {
+                    "type": "integer"
+                },
+                "end_at": {
                     "type": "string"
                 },
-                "content": {
+                "id": {
                     "type": "string"
                 },
-                "postId": {
+                "rule_id": {
+                    "type": "string"
+                },
+                "start_at": {
                     "type": "string"
                 }
             }
         },
-        "models.ImageStatus": {
-            "type": "string",
-            "enum": [
-                "pending",
-                "used"
-            ],
-            "x-enum-varnames": [
-                "ImageStatusPending",
-                "ImageStatusUsed"
-            ]
-        },
-        "models.Post": {
+        "models.TourSlotRule": {
             "type": "object",
             "properties": {
-                "author": {
+                "active": {
+                    "type": "boolean"
+                },
+                "campus": {
                     "type": "string"
                 },
-                "category": {
+                "capacity": {
+                    "type": "integer"
+                },
+                "created_at": {
                     "type": "string"
                 },
-                "content": {
+                "duration_minutes": {
+                    "type": "integer"
+                },
+                "id": {
                     "type": "string"
                 },
-                "create_at": {
+                "start_time": {
                     "type": "string"
                 },
-                "header_image": {
+                "updated_at": {
+                    "type": "string"
+                },
+                "weekday": {
+                    "description": "Weekday is 0 for Sunday to 6 for Saturday",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.TourSlotRulePayload": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "campus": {
+                    "type": "string"
+                },
+                "capacity": {
+                    "type": "integer"
+                },
+                "duration_minutes": {
+                    "type": "integer"
+                },
+                "start_time": {
+                    "type": "string"
+                },
+                "weekday": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.TrashItem": {
+            "type": "object",
+            "properties": {
+                "deleted_at": {
                     "type": "string"
                 },
                 "id": {
                     "type": "string"
                 },
-                "status": {
+                "purge_at": {
                     "type": "string"
                 },
                 "title": {
                     "type": "string"
                 },
-                "update_at": {
+                "type": {
                     "type": "string"
                 }
             }
         },
-        "models.PostDetailResponse": {
+        "models.UpdateCommentPayload": {
             "type": "object",
             "properties": {
-                "images": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.UploadedImage"
-                    }
-                },
-                "post": {
-                    "$ref": "#/definitions/models.Post"
+                "content": {
+                    "type": "string"
                 }
             }
         },
@@ -1935,6 +6087,9 @@ const docTemplate = `{
                 "path": {
                     "type": "string"
                 },
+                "source_url": {
+                    "type": "string"
+                },
                 "status": {
                     "$ref": "#/definitions/models.ImageStatus"
                 },
@@ -1949,6 +6104,13 @@ const docTemplate = `{
         "models.User": {
             "type": "object",
             "properties": {
+                "classes": {
+                    "description": "Classes of a parent's children, for class-restricted posts",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "date_created": {
                     "type": "string"
                 },
@@ -1972,6 +6134,55 @@ const docTemplate = `{
                 }
             }
         },
+        "models.WordPressImportItem": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "legacy_id": {
+                    "type": "string"
+                },
+                "post_id": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.WordPressImportResult": {
+            "type": "object",
+            "properties": {
+                "failed": {
+                    "type": "integer"
+                },
+                "imported": {
+                    "type": "integer"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.WordPressImportItem"
+                    }
+                },
+                "media_copied": {
+                    "type": "integer"
+                },
+                "media_failed": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "skipped": {
+                    "type": "integer"
+                }
+            }
+        },
         "uploaded_image.UpdateImagePayload": {
             "type": "object",
             "properties": {
@@ -0,0 +1,226 @@
+package attachment
+
+import (
+	"amg-backend/config"
+	"amg-backend/middleware"
+	"amg-backend/models"
+	"amg-backend/service"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"io"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// UploadAttachment godoc
+// @Summary Upload a document attachment
+// @Description Uploads a PDF or Office document and returns its record. The attachment stays 'pending' until a post references it through attachment_ids. Staff only.
+// @Tags attachment
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "Document to upload (PDF, DOC(X), XLS(X), PPT(X))"
+// @Success 200 {object} models.Attachment
+// @Failure 400 {object} map[string]string
+// @Failure 413 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /amg/v1/attachments/upload-attachment [post]
+func (h *AttachmentHandler) UploadAttachment(c *fiber.Ctx) error {
+	file, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "File is required"})
+	}
+	if file.Size > service.MaxAttachmentSize {
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+			"error": fmt.Sprintf("Attachment exceeds the %d MB limit", service.MaxAttachmentSize/(1024*1024)),
+		})
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Cannot read file"})
+	}
+	defer src.Close()
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(src, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Cannot read file"})
+	}
+
+	originalName := service.SanitizeFilename(file.Filename)
+	mimeType, err := service.SniffAttachmentType(head[:n], originalName)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Only PDF, Word, Excel and PowerPoint files are allowed"})
+	}
+
+	if err := os.MkdirAll(service.AttachmentDir, 0755); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to save attachment file"})
+	}
+
+	uniqueFilename := uuid.New().String() + strings.ToLower(filepath.Ext(originalName))
+	savePath := fmt.Sprintf("%s/%s", service.AttachmentDir, uniqueFilename)
+	if err := c.SaveFile(file, savePath); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to save attachment file"})
+	}
+
+	record := models.Attachment{
+		ID:           primitive.NewObjectID(),
+		OriginalName: originalName,
+		Filename:     uniqueFilename,
+		Path:         savePath,
+		MimeType:     mimeType,
+		Size:         file.Size,
+		Status:       models.AttachmentStatusPending,
+		CreatedAt:    time.Now(),
+	}
+	record.URL = "/amg/v1/attachments/download/" + record.ID.Hex()
+
+	collection := h.DB.Database(config.DBName).Collection("Attachment")
+	if _, err := collection.InsertOne(context.TODO(), &record); err != nil {
+		os.Remove(savePath)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to record attachment metadata"})
+	}
+
+	return c.JSON(record)
+}
+
+// GetAttachmentsByPost godoc
+// @Summary Get the attachments of a post
+// @Description Retrieves the attachments linked to a post
+// @Tags attachment
+// @Accept json
+// @Produce json
+// @Param postId path string true "Post ID"
+// @Success 200 {array} models.Attachment
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /amg/v1/attachments/get-attachments-by-post/{postId} [get]
+func (h *AttachmentHandler) GetAttachmentsByPost(c *fiber.Ctx) error {
+	postID, err := primitive.ObjectIDFromHex(c.Params("postId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid post ID"})
+	}
+
+	visible, err := service.PostVisibleTo(h.DB, postID, middleware.PostViewer(c, h.DB))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "DB error"})
+	}
+	if !visible {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Post not found"})
+	}
+
+	attachments, err := service.GetPostAttachments(h.DB, postID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "DB error"})
+	}
+
+	return c.JSON(attachments)
+}
+
+// DownloadAttachment godoc
+// @Summary Download an attachment
+// @Description Sends the attachment with its original filename. Use inline=true to let the browser display PDFs. Attachments of a post that is not published are sent with the post's preview token; the URLs in /posts/preview already carry it.
+// @Tags attachment
+// @Produce octet-stream
+// @Param id path string true "Attachment ID"
+// @Param inline query bool false "Display in the browser instead of downloading"
+// @Param token query string false "Preview token of the attachment's post"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /amg/v1/attachments/download/{id} [get]
+func (h *AttachmentHandler) DownloadAttachment(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid attachment ID"})
+	}
+
+	var attachment models.Attachment
+	collection := h.DB.Database(config.DBName).Collection("Attachment")
+	if err := collection.FindOne(context.TODO(), bson.M{"_id": id}).Decode(&attachment); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Attachment not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "DB error"})
+	}
+
+	// Attachments follow the visibility of their post, and a preview link of
+	// the post opens them as well
+	if attachment.PostID != nil {
+		visible, err := service.PostVisibleTo(h.DB, *attachment.PostID, middleware.PostViewer(c, h.DB))
+		if err == nil && !visible {
+			visible, err = service.PreviewTokenGrants(h.DB, *attachment.PostID, c.Query("token"))
+		}
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "DB error"})
+		}
+		if !visible {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Attachment not found"})
+		}
+	}
+
+	f, err := os.Open(attachment.Path)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Attachment file not found"})
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Cannot read attachment file"})
+	}
+
+	disposition := "attachment"
+	if c.QueryBool("inline") {
+		disposition = "inline"
+	}
+	c.Set(fiber.HeaderContentDisposition, mime.FormatMediaType(disposition, map[string]string{"filename": attachment.OriginalName}))
+	c.Set(fiber.HeaderContentType, attachment.MimeType)
+	c.Set("X-Content-Type-Options", "nosniff")
+
+	return c.SendStream(f, int(info.Size()))
+}
+
+// DeleteAttachment godoc
+// @Summary Delete an attachment
+// @Description Detaches an attachment from its post and marks it 'pending' so that the cleanup job removes it. Staff only.
+// @Tags attachment
+// @Accept json
+// @Produce json
+// @Param id path string true "Attachment ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /amg/v1/attachments/delete-attachment/{id} [post]
+func (h *AttachmentHandler) DeleteAttachment(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid attachment ID"})
+	}
+
+	update := bson.M{
+		"$set":   bson.M{"status": models.AttachmentStatusPending},
+		"$unset": bson.M{"post_id": ""},
+	}
+	collection := h.DB.Database(config.DBName).Collection("Attachment")
+	result, err := collection.UpdateByID(context.TODO(), id, update)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Delete failed"})
+	}
+	if result.MatchedCount == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Attachment not found"})
+	}
+
+	return c.JSON(fiber.Map{"message": "Attachment deleted successfully"})
+}
@@ -0,0 +1,29 @@
+package attachment
+
+import (
+	"amg-backend/middleware"
+	"amg-backend/service"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MaxRequestBodySize is how large an upload-attachment request may be.
+const MaxRequestBodySize = service.MaxAttachmentSize + fiber.DefaultBodyLimit
+
+type AttachmentHandler struct {
+	Router fiber.Router
+	DB     *mongo.Client
+}
+
+func RegisterAttachmentHandler(router fiber.Router, db *mongo.Client) {
+	attachmentHandler := AttachmentHandler{
+		Router: router,
+		DB:     db,
+	}
+
+	// Register all endpoints here
+	router.Post("/upload-attachment", middleware.RequireStaff, attachmentHandler.UploadAttachment)
+	router.Get("/get-attachments-by-post/:postId", attachmentHandler.GetAttachmentsByPost)
+	router.Get("/download/:id", attachmentHandler.DownloadAttachment)
+	router.Post("/delete-attachment/:id", middleware.RequireStaff, attachmentHandler.DeleteAttachment)
+}
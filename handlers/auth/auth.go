@@ -66,7 +66,7 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
 		Username: req.Username,
 		Password: string(hashedPassword),
 		Name:     req.Name,
-		Role:     "parent",
+		Role:     models.RoleParent,
 		IsActive: true,
 		CreateAt: time.Now(),
 		UpdateAt: time.Now(),
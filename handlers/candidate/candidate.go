@@ -2,29 +2,37 @@ package candidate
 
 import (
 	"amg-backend/config"
+	"amg-backend/middleware"
 	"amg-backend/models"
+	"amg-backend/service"
 	"context"
 	"errors"
 	"github.com/gofiber/fiber/v2"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"log"
 	"time"
 )
 
 // GetAllCandidates godoc
 // @Summary Get all candidate
-// @Description Retrieves all candidates from the database
+// @Description Retrieves all candidates from the database, each with its latest activity. Candidates flagged as spam are left out unless include_spam=true; they can also be listed with get-candidates-by-status/spam. Staff only.
 // @Tags candidate
 // @Accept json
 // @Produce json
+// @Param include_spam query bool false "Include candidates flagged as spam"
 // @Success 200 {array} models.Candidate
 // @Failure 500 {object} map[string]string
 // @Router /amg/v1/candidates/get-all-candidates [get]
 func (h *CandidateHandler) GetAllCandidates(c *fiber.Ctx) error {
 	var candidates []models.Candidate
+	filter := bson.M{}
+	if !c.QueryBool("include_spam") {
+		filter["status"] = bson.M{"$ne": models.CandidateStatusSpam}
+	}
 	collection := h.DB.Database(config.DBName).Collection("Candidate")
-	cursor, err := collection.Find(context.TODO(), bson.M{})
+	cursor, err := collection.Find(context.TODO(), filter)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "DB error"})
 	}
@@ -39,7 +47,7 @@ func (h *CandidateHandler) GetAllCandidates(c *fiber.Ctx) error {
 
 // GetCandidateById godoc
 // @Summary Get candidate by ID
-// @Description Retrieves a candidate by its ID
+// @Description Retrieves a candidate by its ID. Staff only.
 // @Tags candidate
 // @Accept json
 // @Produce json
@@ -72,7 +80,7 @@ func (h *CandidateHandler) GetCandidateById(c *fiber.Ctx) error {
 
 // GetCandidatesByStatus godoc
 // @Summary Get candidates by status
-// @Description Retrieves candidates by their status
+// @Description Retrieves candidates by their status. Staff only.
 // @Tags candidate
 // @Accept json
 // @Produce json
@@ -88,7 +96,7 @@ func (h *CandidateHandler) GetCandidatesByStatus(c *fiber.Ctx) error {
 	}
 
 	var candidates []models.Candidate
-	collection := h.DB.Database(config.DBName).Collection("Post")
+	collection := h.DB.Database(config.DBName).Collection("Candidate")
 	cursor, err := collection.Find(context.TODO(), bson.M{"status": status})
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "DB error"})
@@ -107,9 +115,20 @@ func (h *CandidateHandler) GetCandidatesByStatus(c *fiber.Ctx) error {
 	return c.JSON(candidates)
 }
 
+// editableCandidateFields are the fields UpdateCandidate may change. The stage
+// only changes through TransitionCandidate so that the funnel is enforced.
+var editableCandidateFields = map[string]bool{
+	"student_name": true,
+	"gender":       true,
+	"dob":          true,
+	"parent_name":  true,
+	"address":      true,
+	"phone":        true,
+}
+
 // UpdateCandidate godoc
 // @Summary Update a candidate
-// @Description Updates a candidate's information by its ID
+// @Description Updates a candidate's details by its ID: student_name, gender, dob, parent_name, address and phone. The phone and date of birth are validated and normalised as in create-candidate; invalid fields are listed in 'fields' of a 400 response. The status is changed with transition-candidate.
 // @Tags candidate
 // @Accept json
 // @Produce json
@@ -117,35 +136,129 @@ func (h *CandidateHandler) GetCandidatesByStatus(c *fiber.Ctx) error {
 // @Param candidate body models.Candidate true "Candidate data to update"
 // @Success 200 {object} map[string]string
 // @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /amg/v1/candidates/update-candidate/{id} [post]
 func (h *CandidateHandler) UpdateCandidate(c *fiber.Ctx) error {
 	idParam := c.Params("id")
-	id, _ := primitive.ObjectIDFromHex(idParam)
+	id, err := primitive.ObjectIDFromHex(idParam)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid candidate ID"})
+	}
 
 	updateData := bson.M{}
 	if err := c.BodyParser(&updateData); err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": "invalid input"})
 	}
+	for field := range updateData {
+		if field == "status" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "status is changed with transition-candidate"})
+		}
+		if !editableCandidateFields[field] {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "field cannot be updated: " + field})
+		}
+	}
+	unset, err := service.ValidateCandidateUpdate(updateData)
+	if err != nil {
+		return validationError(c, err)
+	}
 	updateData["update_at"] = time.Now()
+	update := bson.M{"$set": updateData}
+	if len(unset) > 0 {
+		fields := bson.M{}
+		for _, field := range unset {
+			fields[field] = ""
+		}
+		update["$unset"] = fields
+	}
 
 	collection := h.DB.Database(config.DBName).Collection("Candidate")
-	_, err := collection.UpdateByID(context.TODO(), id, bson.M{"$set": updateData})
+	result, err := collection.UpdateOne(context.TODO(),
+		bson.M{"_id": id, "status": bson.M{"$ne": models.StatusDeleted}},
+		update,
+	)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "update failed"})
 	}
+	if result.MatchedCount == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Candidate not found"})
+	}
+	if err := service.RefreshCandidateMatchKeys(h.DB, id); err != nil {
+		log.Printf("Warning: could not refresh match keys of candidate %s: %v", id.Hex(), err)
+	}
 	return c.JSON(fiber.Map{"message": "updated"})
 }
 
+// validationError reports the invalid fields of a candidate, or fails with
+// 500 if err is not a validation error.
+func validationError(c *fiber.Ctx, err error) error {
+	var fields service.FieldErrors
+	if errors.As(err, &fields) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Please check the highlighted fields", "fields": fields})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "DB error"})
+}
+
+// TransitionCandidate godoc
+// @Summary Move a candidate to another admissions stage
+// @Description Moves a candidate through the admissions funnel: new → contacted → tour_scheduled → applied → offered → enrolled, or out of it as declined or lost (a reason is required). Stages may be skipped going forward, declined and lost candidates can be reopened as contacted, and spam can be released as new. Other moves are rejected with 409. The time each stage was entered is kept in stage_dates, and the move is added to the candidate's activity timeline. Closing a candidate as declined or lost cancels their booked tour and frees the place.
+// @Tags candidate
+// @Accept json
+// @Produce json
+// @Param id path string true "Candidate ID"
+// @Param body body models.CandidateTransitionPayload true "Target status and, for declined or lost, the reason"
+// @Success 200 {object} models.Candidate
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /amg/v1/candidates/transition-candidate/{id} [post]
+func (h *CandidateHandler) TransitionCandidate(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid candidate ID"})
+	}
+	var payload models.CandidateTransitionPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid input"})
+	}
+
+	user, _ := middleware.CurrentUser(c)
+	candidate, err := service.TransitionCandidate(h.DB, id, payload.Status, payload.Reason, user.ID, user.Username)
+	switch {
+	case err == nil:
+		return c.JSON(candidate)
+	case errors.Is(err, service.ErrCandidateNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Candidate not found"})
+	case errors.Is(err, service.ErrUnknownCandidateStatus):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Unknown status"})
+	case errors.Is(err, service.ErrClosedReasonRequired):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "reason is required for declined and lost"})
+	case errors.Is(err, service.ErrIllegalTransition):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   "Cannot move candidate from " + service.CandidateStage(candidate.Status) + " to " + payload.Status,
+			"allowed": service.NextCandidateStatuses(candidate.Status),
+		})
+	case errors.Is(err, service.ErrCandidateChanged):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Candidate was changed meanwhile, reload and try again"})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "DB error"})
+}
+
 // CreateCandidate godoc
 // @Summary Create a new candidate
-// @Description Creates a new candidate in the database
+// @Description Creates a new candidate in the database. student_name and phone are required. The phone must be a Vietnamese mobile or landline number and is saved in E.164 (+84...); dob is read day first (25/12/2021, 1/2/20 or 2021-12-25), saved as YYYY-MM-DD with birth_date, and sets the child's kindergarten age_group (nha_tre, mam, choi or la). Invalid fields are listed in 'fields' of a 400 response, e.g. {"error": "...", "fields": {"phone": "..."}}. Submissions flagged by the content filters (profanity, links, repetition, or a filled-in 'website' honeypot field) are stored with status 'spam' for staff to review. Submissions with the same phone number, or the same parent name and child's date of birth, as an existing candidate are saved with possible_duplicates set for staff to merge or dismiss. An optional 'tour_slot_id' from /tours/get-available-slots books a campus tour together with the form; if the slot is full nothing is saved and 409 is returned.
 // @Tags candidate
 // @Accept json
 // @Produce json
 // @Param candidate body models.Candidate true "Candidate data"
+// @Param X-Captcha-Token header string false "Token from /captcha/get-challenge; not needed when logged in"
+// @Param X-Captcha-Solution header string false "Nonce or answer solving the challenge"
 // @Success 200 {object} models.Candidate
 // @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /amg/v1/candidates/create-candidate [post]
 func (h *CandidateHandler) CreateCandidate(c *fiber.Ctx) error {
@@ -157,7 +270,56 @@ func (h *CandidateHandler) CreateCandidate(c *fiber.Ctx) error {
 	candidate.ID = primitive.NewObjectID()
 	candidate.CreateAt = time.Now()
 	candidate.UpdateAt = time.Now()
-	candidate.Status = "new" // Default status
+	candidate.Status = models.CandidateStatusNew
+	candidate.StageDates = map[string]time.Time{models.CandidateStatusNew: candidate.CreateAt}
+	candidate.ClosedReason = ""
+	candidate.Tour = nil
+	candidate.LastActivity = nil
+	candidate.PossibleDuplicates = nil
+	candidate.MergedInto = nil
+	if err := service.ValidateCandidate(&candidate); err != nil {
+		return validationError(c, err)
+	}
+	candidate.MatchKeys = service.CandidateMatchKeysOf(candidate)
+
+	// The honeypot and the chosen tour are not part of the candidate, so they
+	// are read separately
+	var extra struct {
+		Website    string `json:"website"`
+		TourSlotID string `json:"tour_slot_id"`
+	}
+	_ = c.BodyParser(&extra)
+
+	var tourSlotID primitive.ObjectID
+	if extra.TourSlotID != "" {
+		id, err := primitive.ObjectIDFromHex(extra.TourSlotID)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid tour_slot_id"})
+		}
+		tourSlotID = id
+	}
+
+	verdict := service.CheckSubmission(service.Submission{
+		Fields:   []string{candidate.StudentName, candidate.ParentName, candidate.Address},
+		Honeypot: extra.Website,
+	})
+	candidate.SpamScore = verdict.Score
+	candidate.SpamReasons = nil
+	if verdict.Score > 0 {
+		candidate.SpamReasons = verdict.Reasons
+	}
+	if verdict.IsSpam {
+		candidate.Status = models.CandidateStatusSpam
+	} else {
+		// A family who sends the form again is flagged for staff to merge
+		duplicates, err := service.FindCandidateDuplicates(h.DB, candidate)
+		if err != nil {
+			log.Printf("Warning: could not look for duplicates of candidate %s: %v", candidate.ID.Hex(), err)
+		}
+		for _, duplicate := range duplicates {
+			candidate.PossibleDuplicates = append(candidate.PossibleDuplicates, duplicate.ID)
+		}
+	}
 
 	collection := h.DB.Database(config.DBName).Collection("Candidate")
 	_, err := collection.InsertOne(context.TODO(), candidate)
@@ -165,59 +327,102 @@ func (h *CandidateHandler) CreateCandidate(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create candidate"})
 	}
 
+	// The submitter is not told what the filters found or which other
+	// families their form matched
+	candidate.PossibleDuplicates = nil
+	candidate.SpamScore = 0
+	candidate.SpamReasons = nil
+
+	if !tourSlotID.IsZero() && !verdict.IsSpam {
+		booking, err := service.BookTour(h.DB, candidate.ID, tourSlotID, "", service.PublicFormAuthor)
+		if err != nil {
+			// The family picks another slot and sends the form again
+			h.discardCandidate(candidate.ID)
+			switch {
+			case errors.Is(err, service.ErrTourSlotFull):
+				return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "This tour is fully booked, please pick another time"})
+			case errors.Is(err, service.ErrTourSlotNotFound):
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Tour slot not found or already past"})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to book the tour"})
+		}
+		candidate.Tour = &models.CandidateTour{BookingID: booking.ID, Campus: booking.Campus, StartAt: booking.StartAt}
+		candidate.Status = models.CandidateStatusTourScheduled
+		candidate.StageDates[models.CandidateStatusTourScheduled] = booking.CreatedAt
+		return c.JSON(candidate)
+	}
+
+	// Nor that it was flagged as spam
+	candidate.Status = models.CandidateStatusNew
 	return c.JSON(candidate)
 }
 
+// discardCandidate removes a candidate created by a form that could not be
+// completed, together with anything recorded for it.
+func (h *CandidateHandler) discardCandidate(id primitive.ObjectID) {
+	database := h.DB.Database(config.DBName)
+	if _, err := database.Collection("Candidate").DeleteOne(context.TODO(), bson.M{"_id": id}); err != nil {
+		log.Printf("Warning: could not discard candidate %s: %v", id.Hex(), err)
+	}
+	if _, err := database.Collection("CandidateActivity").DeleteMany(context.TODO(), bson.M{"candidate_id": id}); err != nil {
+		log.Printf("Warning: could not discard activities of candidate %s: %v", id.Hex(), err)
+	}
+}
+
 // DeleteCandidate godoc
 // @Summary Delete a candidate
-// @Description Deletes a candidate by its ID
+// @Description Moves a candidate to the trash. It is purged permanently after the retention period. Staff only.
 // @Tags candidate
 // @Accept json
 // @Produce json
 // @Param id path string true "Candidate ID"
 // @Success 200 {object} map[string]string
 // @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /amg/v1/candidates/delete-candidate/{id} [post]
 func (h *CandidateHandler) DeleteCandidate(c *fiber.Ctx) error {
 	idParam := c.Params("id")
-	id, _ := primitive.ObjectIDFromHex(idParam)
-
-	updateData := bson.M{}
-	updateData["update_at"] = time.Now()
-	updateData["status"] = "deleted"
+	id, err := primitive.ObjectIDFromHex(idParam)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid candidate ID"})
+	}
 
-	collection := h.DB.Database(config.DBName).Collection("Candidate")
-	_, err := collection.UpdateByID(context.TODO(), id, bson.M{"$set": updateData})
+	found, err := service.MoveToTrash(h.DB, models.TrashTypeCandidate, id)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "delete failed"})
 	}
+	if !found {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Candidate not found"})
+	}
 	return c.JSON(fiber.Map{"message": "deleted"})
 }
 
 // RecoveryCandidate godoc
 // @Summary Recover a deleted candidate
-// @Description Recovers a candidate by its ID
+// @Description Restores a candidate from the trash to the status it had before deletion. Staff only.
 // @Tags candidate
 // @Accept json
 // @Produce json
 // @Param id path string true "Candidate ID"
 // @Success 200 {object} map[string]string
 // @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /amg/v1/candidates/recovery-candidate/{id} [post]
 func (h *CandidateHandler) RecoveryCandidate(c *fiber.Ctx) error {
 	idParam := c.Params("id")
-	id, _ := primitive.ObjectIDFromHex(idParam)
-
-	updateData := bson.M{}
-	updateData["update_at"] = time.Now()
-	updateData["status"] = "recovered"
+	id, err := primitive.ObjectIDFromHex(idParam)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid candidate ID"})
+	}
 
-	collection := h.DB.Database(config.DBName).Collection("Candidate")
-	_, err := collection.UpdateByID(context.TODO(), id, bson.M{"$set": updateData})
+	found, err := service.RestoreFromTrash(h.DB, models.TrashTypeCandidate, id)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "recovery failed"})
 	}
+	if !found {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Deleted candidate not found"})
+	}
 	return c.JSON(fiber.Map{"message": "recovered"})
 }
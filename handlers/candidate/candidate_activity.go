@@ -0,0 +1,94 @@
+package candidate
+
+import (
+	"amg-backend/middleware"
+	"amg-backend/models"
+	"amg-backend/service"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"strings"
+)
+
+// AddCandidateActivity godoc
+// @Summary Add an entry to a candidate's timeline
+// @Description Records a note, call or email with a candidate's family, with the channel used and optionally when to follow up. The entry becomes the candidate's latest activity. Status changes are recorded by transition-candidate. Staff only.
+// @Tags candidate
+// @Accept json
+// @Produce json
+// @Param id path string true "Candidate ID"
+// @Param body body models.AddCandidateActivityPayload true "Activity"
+// @Success 201 {object} models.CandidateActivity
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /amg/v1/candidates/add-activity/{id} [post]
+func (h *CandidateHandler) AddCandidateActivity(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid candidate ID"})
+	}
+	var payload models.AddCandidateActivityPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid input"})
+	}
+
+	kind, err := service.ParseActivityType(payload.Type)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "type must be 'note', 'call' or 'email'"})
+	}
+	content := strings.TrimSpace(payload.Content)
+	if kind == models.CandidateActivityNote && content == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "content is required for notes"})
+	}
+	followUp, err := service.ParseFollowUpDate(payload.NextFollowUp)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "next_follow_up must be a date (YYYY-MM-DD) or an RFC 3339 time"})
+	}
+
+	if _, err := service.FindCandidate(h.DB, id); err != nil {
+		if errors.Is(err, service.ErrCandidateNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Candidate not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "DB error"})
+	}
+
+	user, _ := middleware.CurrentUser(c)
+	activity, err := service.AddCandidateActivity(h.DB, models.CandidateActivity{
+		CandidateID:  id,
+		Type:         kind,
+		Channel:      strings.TrimSpace(payload.Channel),
+		Content:      content,
+		AuthorID:     user.ID,
+		AuthorName:   user.Username,
+		NextFollowUp: followUp,
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to add activity"})
+	}
+	return c.Status(fiber.StatusCreated).JSON(activity)
+}
+
+// GetCandidateActivities godoc
+// @Summary Get a candidate's timeline
+// @Description Lists the notes, calls, emails and status changes of a candidate, newest first. Staff only.
+// @Tags candidate
+// @Accept json
+// @Produce json
+// @Param id path string true "Candidate ID"
+// @Success 200 {array} models.CandidateActivity
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /amg/v1/candidates/get-activities/{id} [get]
+func (h *CandidateHandler) GetCandidateActivities(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid candidate ID"})
+	}
+
+	activities, err := service.ListCandidateActivities(h.DB, id)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "DB error"})
+	}
+	return c.JSON(activities)
+}
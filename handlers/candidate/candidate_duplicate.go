@@ -0,0 +1,99 @@
+package candidate
+
+import (
+	"amg-backend/middleware"
+	"amg-backend/models"
+	"amg-backend/service"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GetPossibleDuplicates godoc
+// @Summary List candidates flagged as duplicates
+// @Description Lists the candidates whose form matched an existing candidate by phone number, or by parent name and child's date of birth, newest first, each with the candidates it matched that still exist. Staff only.
+// @Tags candidate
+// @Accept json
+// @Produce json
+// @Success 200 {array} models.CandidateDuplicates
+// @Failure 500 {object} map[string]string
+// @Router /amg/v1/candidates/get-possible-duplicates [get]
+func (h *CandidateHandler) GetPossibleDuplicates(c *fiber.Ctx) error {
+	duplicates, err := service.ListPossibleDuplicates(h.DB)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "DB error"})
+	}
+	return c.JSON(duplicates)
+}
+
+// DismissDuplicates godoc
+// @Summary Clear a candidate's duplicate flag
+// @Description Marks a flagged candidate as a different family from the candidates it matched. Staff only.
+// @Tags candidate
+// @Accept json
+// @Produce json
+// @Param id path string true "Candidate ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /amg/v1/candidates/dismiss-duplicates/{id} [post]
+func (h *CandidateHandler) DismissDuplicates(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid candidate ID"})
+	}
+
+	err = service.DismissCandidateDuplicates(h.DB, id)
+	if errors.Is(err, service.ErrCandidateNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Candidate not found"})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "DB error"})
+	}
+	return c.JSON(fiber.Map{"message": "dismissed"})
+}
+
+// MergeCandidate godoc
+// @Summary Merge a duplicate into a candidate
+// @Description Folds duplicate_id into the candidate. Empty fields are filled from the duplicate and names and address keep the fuller version; the candidate takes the furthest admissions stage of the two. The duplicate's activity timeline and tour booking move over (if both have a tour, the duplicate's is cancelled), and the duplicate goes to the trash with merged_into set. A merge that failed part-way is completed by sending it again. Staff only.
+// @Tags candidate
+// @Accept json
+// @Produce json
+// @Param id path string true "ID of the candidate to keep"
+// @Param body body models.MergeCandidatePayload true "Duplicate to merge"
+// @Success 200 {object} models.Candidate
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /amg/v1/candidates/merge-candidate/{id} [post]
+func (h *CandidateHandler) MergeCandidate(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid candidate ID"})
+	}
+	var payload models.MergeCandidatePayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid input"})
+	}
+	duplicateID, err := primitive.ObjectIDFromHex(payload.DuplicateID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid duplicate_id"})
+	}
+
+	user, _ := middleware.CurrentUser(c)
+	candidate, err := service.MergeCandidates(h.DB, id, duplicateID, user.ID, user.Username)
+	switch {
+	case err == nil:
+		return c.JSON(candidate)
+	case errors.Is(err, service.ErrMergeSameCandidate):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "A candidate cannot be merged into itself"})
+	case errors.Is(err, service.ErrCandidateNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Candidate not found"})
+	case errors.Is(err, service.ErrCandidateMerged):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "One of the candidates was already merged into another candidate"})
+	}
+	// Every step of a merge can be repeated, so sending it again completes it
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to merge candidates, please try again"})
+}
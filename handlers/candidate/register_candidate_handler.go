@@ -1,6 +1,7 @@
 package candidate
 
 import (
+	"amg-backend/middleware"
 	"github.com/gofiber/fiber/v2"
 	"go.mongodb.org/mongo-driver/mongo"
 )
@@ -17,11 +18,17 @@ func RegisterCandidateHandler(router fiber.Router, db *mongo.Client) {
 	}
 
 	// Register all endpoints here
-	router.Get("/get-all-candidates", candidateHandler.GetAllCandidates)
-	router.Get("/get-candidate/:id", candidateHandler.GetCandidateById)
-	router.Get("/get-candidates-by-status/:status", candidateHandler.GetCandidatesByStatus)
+	router.Get("/get-all-candidates", middleware.RequireStaff, candidateHandler.GetAllCandidates)
+	router.Get("/get-candidate/:id", middleware.RequireStaff, candidateHandler.GetCandidateById)
+	router.Get("/get-candidates-by-status/:status", middleware.RequireStaff, candidateHandler.GetCandidatesByStatus)
 	router.Post("/update-candidate/:id", candidateHandler.UpdateCandidate)
-	router.Post("/create-candidate", candidateHandler.CreateCandidate)
-	router.Post("/delete-candidate/:id", candidateHandler.DeleteCandidate)
-	router.Post("/recovery-candidate/:id", candidateHandler.RecoveryCandidate)
+	router.Post("/transition-candidate/:id", middleware.RequireStaff, candidateHandler.TransitionCandidate)
+	router.Post("/add-activity/:id", middleware.RequireStaff, candidateHandler.AddCandidateActivity)
+	router.Get("/get-activities/:id", middleware.RequireStaff, candidateHandler.GetCandidateActivities)
+	router.Get("/get-possible-duplicates", middleware.RequireStaff, candidateHandler.GetPossibleDuplicates)
+	router.Post("/dismiss-duplicates/:id", middleware.RequireStaff, candidateHandler.DismissDuplicates)
+	router.Post("/merge-candidate/:id", middleware.RequireStaff, candidateHandler.MergeCandidate)
+	router.Post("/create-candidate", middleware.RequireCaptcha(db), candidateHandler.CreateCandidate)
+	router.Post("/delete-candidate/:id", middleware.RequireStaff, candidateHandler.DeleteCandidate)
+	router.Post("/recovery-candidate/:id", middleware.RequireStaff, candidateHandler.RecoveryCandidate)
 }
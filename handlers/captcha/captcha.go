@@ -0,0 +1,24 @@
+package captcha
+
+import (
+	"amg-backend/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetChallenge godoc
+// @Summary Get a captcha challenge
+// @Description Issues a signed challenge that anonymous visitors must solve before create-comment and create-candidate. For kind 'pow' find a nonce such that sha256(challenge + ":" + nonce) starts with 'difficulty' zero bits; for kind 'arithmetic' answer the question. Send the token and the nonce or answer in the X-Captcha-Token and X-Captcha-Solution headers. Each challenge can be used once and expires after 10 minutes.
+// @Tags captcha
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.CaptchaChallenge
+// @Failure 500 {object} map[string]string
+// @Router /amg/v1/captcha/get-challenge [get]
+func (h *CaptchaHandler) GetChallenge(c *fiber.Ctx) error {
+	challenge, err := service.NewCaptchaChallenge()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not create challenge"})
+	}
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.JSON(challenge)
+}
@@ -0,0 +1,21 @@
+package captcha
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type CaptchaHandler struct {
+	Router fiber.Router
+	DB     *mongo.Client
+}
+
+func RegisterCaptchaHandler(router fiber.Router, db *mongo.Client) {
+	captchaHandler := CaptchaHandler{
+		Router: router,
+		DB:     db,
+	}
+
+	// Register all endpoints here
+	router.Get("/get-challenge", captchaHandler.GetChallenge)
+}
@@ -2,44 +2,52 @@ package comment
 
 import (
 	"amg-backend/config"
+	"amg-backend/middleware"
 	"amg-backend/models"
+	"amg-backend/service"
 	"context"
+	"errors"
 	"github.com/gofiber/fiber/v2"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"strings"
 	"time"
 )
 
 // GetCommentsByPostId godoc
 // @Summary Get comments by post-ID
-// @Description Get all comments for a specific post
+// @Description Get all comments for a specific post with their replies. By default comments are listed flat in reading order with their depth; tree=true nests replies under the comment they answer. Only published comments are shown to readers, staff also see pending, rejected and spam ones. Deleted or hidden comments that still have replies are returned as empty placeholders. Each comment carries its reaction counts and, for logged-in users, their own reaction.
 // @Tags Comment
 // @Accept json
 // @Produce json
 // @Param postId query string true "Post ID"
-// @Success 200 {array} models.Comment
+// @Param tree query bool false "Nest replies instead of listing them flat"
+// @Success 200 {array} models.CommentView
 // @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
 // @Failure 500 {object} map[string]string
-// @Router /amg/v1/comments/get-comments-by-post [get]
+// @Router /amg/v1/comments/get-comments-in-post [get]
 func (h *CommentHandler) GetCommentsByPostId(c *fiber.Ctx) error {
 	postId := c.Query("postId")
 	if postId == "" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "postId is required"})
 	}
+	if ok, err := h.canSeePost(c, postId); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "DB error"})
+	} else if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Post not found"})
+	}
 
 	var comments []models.Comment
 	collection := h.DB.Database(config.DBName).Collection("Comment")
 
+	// Deleted comments are loaded too: they stay as placeholders while they have replies
 	filter := bson.M{
 		"post_id": postId,
-		"status":  bson.M{"$ne": "deleted"},
 	}
 
-	findOptions := options.Find()
-	findOptions.SetSort(bson.D{{Key: "created_at", Value: -1}})
-
-	cursor, err := collection.Find(context.TODO(), filter, findOptions)
+	cursor, err := collection.Find(context.TODO(), filter)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "DB error"})
 	}
@@ -49,22 +57,34 @@ func (h *CommentHandler) GetCommentsByPostId(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to decode comments"})
 	}
 
-	if comments == nil {
-		comments = make([]models.Comment, 0)
+	user, ok := middleware.CurrentUser(c)
+	threads := service.BuildCommentThreads(comments, ok && user.IsStaff())
+	var userID string
+	if ok {
+		userID = user.ID
 	}
-
-	return c.JSON(comments)
+	if err := service.AttachCommentReactions(h.DB, threads, userID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "DB error"})
+	}
+	if c.QueryBool("tree") {
+		return c.JSON(threads)
+	}
+	return c.JSON(service.FlattenCommentThreads(threads))
 }
 
 // CreateComment godoc
 // @Summary Create a new comment
-// @Description Create a new comment for a post
+// @Description Create a new comment for a post, or a reply to another comment with parentId. Logged-in users comment under the name on their account (or a neutral label if it has none), whatever authorName says. Replies of logged-in staff are marked as such. Depending on the moderation mode of the post's category the comment is published at once or created with status 'pending' until staff approve it. Comments flagged by the content filters (profanity, links, repetition, or a filled-in 'website' honeypot field) go to the moderation queue with status 'spam'.
 // @Tags Comment
 // @Accept json
 // @Produce json
 // @Param comment body models.CreateCommentPayload true "Data to create a comment"
+// @Param X-Captcha-Token header string false "Token from /captcha/get-challenge; not needed when logged in"
+// @Param X-Captcha-Solution header string false "Nonce or answer solving the challenge"
 // @Success 201 {object} models.Comment
 // @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /amg/v1/comments/create-comment [post]
 func (h *CommentHandler) CreateComment(c *fiber.Ctx) error {
@@ -73,39 +93,87 @@ func (h *CommentHandler) CreateComment(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
 	}
 
+	// Signed-in users always comment under the name on their account, so
+	// nobody can pose as staff or another parent
+	if user, ok := middleware.CurrentUser(c); ok {
+		payload.AuthorName = service.CommentAuthorName(h.DB, user.ID, user.Role)
+	}
 	if payload.PostId == "" || payload.AuthorName == "" || payload.Content == "" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "postId, authorName, và content là bắt buộc"})
 	}
+	if ok, err := h.canSeePost(c, payload.PostId); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "DB error"})
+	} else if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Post not found"})
+	}
+
+	parentId, depth, err := service.ReplyPlacement(h.DB, payload.PostId, payload.ParentId)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidParentComment) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid parentId"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "DB error"})
+	}
 
 	comment := models.Comment{
 		ID:         primitive.NewObjectID(),
 		PostId:     payload.PostId,
-		AuthorId:   payload.AuthorId,
 		AuthorName: payload.AuthorName,
 		Content:    payload.Content,
-		Status:     "new",
 		CreatedAt:  time.Now(),
 		UpdatedAt:  time.Now(),
+		ParentId:   parentId,
+		Depth:      depth,
 	}
+	// The author and their role, which marks staff replies, are only taken
+	// from the session; anonymous comments have no author
+	var sessionUserID string
+	if user, ok := middleware.CurrentUser(c); ok {
+		sessionUserID = user.ID
+		comment.AuthorId = user.ID
+		comment.AuthorRole = user.Role
+	}
+
+	postID, _ := primitive.ObjectIDFromHex(payload.PostId)
+	comment.Status, err = service.InitialCommentStatus(h.DB, postID, sessionUserID, comment.AuthorRole)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "DB error"})
+	}
+
+	// Suspicious comments go to the spam queue instead of being rejected
+	service.ScreenComment(&comment, payload.Website)
 
 	collection := h.DB.Database(config.DBName).Collection("Comment")
-	_, err := collection.InsertOne(context.TODO(), comment)
+	_, err = collection.InsertOne(context.TODO(), comment)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create comment"})
 	}
+	if models.IsPublishedCommentStatus(comment.Status) {
+		if err := service.RefreshPostCommentStats(h.DB, comment.PostId); err != nil {
+			log.Printf("Warning: could not refresh comment stats of post %s: %v", comment.PostId, err)
+		}
+	}
 
+	// The author is not told their comment was flagged
+	if comment.Status == models.CommentStatusSpam {
+		comment.Status = models.CommentStatusPending
+	}
+	comment.SpamScore = 0
+	comment.SpamReasons = nil
 	return c.Status(fiber.StatusCreated).JSON(comment)
 }
 
 // DeleteComment godoc
 // @Summary Delete a comment
-// @Description Mark a comment as deleted by ID
+// @Description Move a comment to the trash by ID. Authors can delete their own comments within COMMENT.EDIT_WINDOW_MINUTES of posting them, staff any comment. It is purged permanently after the retention period. Its replies stay visible under an empty placeholder, and move up a level once it is purged.
 // @Tags Comment
 // @Accept json
 // @Produce json
 // @Param id path string true "Comment ID"
 // @Success 200 {object} map[string]string
 // @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Failure 404 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /amg/v1/comments/delete-comment/{id} [post]
@@ -120,18 +188,16 @@ func (h *CommentHandler) DeleteComment(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
 	}
 
-	updateData := bson.M{
-		"updated_at": time.Now(),
-		"status":     "deleted",
+	if ok, err := h.checkOwnership(c, objID); !ok {
+		return err
 	}
 
-	collection := h.DB.Database(config.DBName).Collection("Comment")
-	result, err := collection.UpdateOne(context.TODO(), bson.M{"_id": objID}, bson.M{"$set": updateData})
+	found, err := service.MoveToTrash(h.DB, models.TrashTypeComment, objID)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Delete failed"})
 	}
 
-	if result.MatchedCount == 0 {
+	if !found {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Comment not found"})
 	}
 
@@ -140,36 +206,126 @@ func (h *CommentHandler) DeleteComment(c *fiber.Ctx) error {
 
 // UpdateComment godoc
 // @Summary Update a comment
-// @Description Update a comment by ID
+// @Description Replace the content of a comment. Authors can edit their own comments within COMMENT.EDIT_WINDOW_MINUTES of posting them, staff any comment. The previous content is kept in the comment's history and the comment is marked as edited. Edits by anyone but staff are screened again and may send the comment back to moderation.
 // @Tags Comment
 // @Accept json
 // @Produce json
 // @Param id path string true "Comment ID"
-// @Param comment body models.Comment true "Comment data"
-// @Success 200 {object} map[string]string
+// @Param comment body models.UpdateCommentPayload true "New content"
+// @Success 200 {object} models.Comment
 // @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /amg/v1/comments/update-comment/{id} [post]
 func (h *CommentHandler) UpdateComment(c *fiber.Ctx) error {
-	var comment models.Comment
-	if err := c.BodyParser(&comment); err != nil {
+	var payload models.UpdateCommentPayload
+	if err := c.BodyParser(&payload); err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": "Invalid input"})
 	}
 	idParam := c.Params("id")
 	id, err := primitive.ObjectIDFromHex(idParam)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid post ID format"})
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid comment ID format"})
+	}
+	if strings.TrimSpace(payload.Content) == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "content là bắt buộc"})
+	}
+
+	user, ok := middleware.CurrentUser(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "not authenticated"})
+	}
+	comment, err := service.EditComment(h.DB, id, payload.Content, user.ID, user.Role)
+	if err != nil {
+		return ownershipError(c, err)
+	}
+	if !user.IsStaff() {
+		// The author is not told their comment was flagged
+		if comment.Status == models.CommentStatusSpam {
+			comment.Status = models.CommentStatusPending
+		}
+		comment.SpamScore = 0
+		comment.SpamReasons = nil
 	}
+	return c.JSON(comment)
+}
 
-	updateData := bson.M{
-		"updated_at": time.Now(),
-		"content":    comment.Content,
+// GetCommentHistory godoc
+// @Summary Get the edit history of a comment
+// @Description Lists the earlier versions of an edited comment, oldest first. Available to staff and to the comment's author.
+// @Tags Comment
+// @Accept json
+// @Produce json
+// @Param id path string true "Comment ID"
+// @Success 200 {array} models.CommentRevision
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /amg/v1/comments/get-comment-history/{id} [get]
+func (h *CommentHandler) GetCommentHistory(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid comment ID format"})
 	}
 
-	collection := h.DB.Database(config.DBName).Collection("Comment")
-	_, err = collection.UpdateByID(context.TODO(), id, bson.M{"$set": updateData})
+	user, ok := middleware.CurrentUser(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "not authenticated"})
+	}
+	comment, err := service.FindComment(h.DB, id)
+	if err != nil {
+		return ownershipError(c, err)
+	}
+	if !user.IsStaff() && comment.AuthorId != user.ID {
+		return ownershipError(c, service.ErrCommentForbidden)
+	}
+
+	revisions, err := service.CommentHistory(h.DB, id)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "DB error"})
+	}
+	return c.JSON(revisions)
+}
+
+// checkOwnership reports whether the requester may change the comment, and
+// sends the error response if not.
+func (h *CommentHandler) checkOwnership(c *fiber.Ctx, id primitive.ObjectID) (bool, error) {
+	user, ok := middleware.CurrentUser(c)
+	if !ok {
+		return false, c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "not authenticated"})
+	}
+	comment, err := service.FindComment(h.DB, id)
+	if err != nil {
+		return false, ownershipError(c, err)
+	}
+	if err := service.CheckCommentOwnership(comment, user.ID, user.Role); err != nil {
+		return false, ownershipError(c, err)
+	}
+	return true, nil
+}
+
+func ownershipError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, service.ErrCommentNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Comment not found"})
+	case errors.Is(err, service.ErrCommentForbidden):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Only the author or staff can change this comment"})
+	case errors.Is(err, service.ErrCommentEditWindowOver):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "The edit window for this comment has passed"})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "DB error"})
+}
+
+// canSeePost reports whether the requester may read the post a comment
+// belongs to; comments of restricted posts are as private as the post.
+func (h *CommentHandler) canSeePost(c *fiber.Ctx, postId string) (bool, error) {
+	id, err := primitive.ObjectIDFromHex(postId)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "DB error"})
+		return false, nil
 	}
-	return c.JSON(fiber.Map{"message": "Comment updated successfully"})
+	return service.PostVisibleTo(h.DB, id, middleware.PostViewer(c, h.DB))
 }
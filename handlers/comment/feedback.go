@@ -0,0 +1,187 @@
+package comment
+
+import (
+	"amg-backend/middleware"
+	"amg-backend/models"
+	"amg-backend/service"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"strings"
+	"unicode/utf8"
+)
+
+// ReactToComment godoc
+// @Summary React to a comment
+// @Description Sets the logged-in user's reaction to a published comment: like, heart or thanks. A user has one reaction per comment; reacting again replaces it.
+// @Tags Comment
+// @Accept json
+// @Produce json
+// @Param id path string true "Comment ID"
+// @Param body body models.ReactCommentPayload true "Reaction type"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /amg/v1/comments/react/{id} [post]
+func (h *CommentHandler) ReactToComment(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+	var payload models.ReactCommentPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+
+	user, ok := middleware.CurrentUser(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "not authenticated"})
+	}
+	if ok, err := h.canSeeComment(c, id); !ok {
+		return err
+	}
+
+	err = service.ReactToComment(h.DB, id, user.ID, strings.ToLower(strings.TrimSpace(payload.Type)))
+	if err != nil {
+		return feedbackError(c, err)
+	}
+	return c.JSON(fiber.Map{"message": "Reaction saved"})
+}
+
+// RemoveCommentReaction godoc
+// @Summary Remove a reaction from a comment
+// @Description Withdraws the logged-in user's reaction to a comment.
+// @Tags Comment
+// @Accept json
+// @Produce json
+// @Param id path string true "Comment ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /amg/v1/comments/remove-reaction/{id} [post]
+func (h *CommentHandler) RemoveCommentReaction(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+	user, ok := middleware.CurrentUser(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "not authenticated"})
+	}
+
+	found, err := service.RemoveCommentReaction(h.DB, id, user.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "DB error"})
+	}
+	if !found {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Reaction not found"})
+	}
+	return c.JSON(fiber.Map{"message": "Reaction removed"})
+}
+
+// ReportComment godoc
+// @Summary Report a comment
+// @Description Flags a published comment as inappropriate. Each reader can report a comment once; anonymous readers are told apart by IP and user agent and must solve a captcha. Once COMMENT.REPORT_THRESHOLD signed-in readers have reported it the comment is hidden and goes back to the moderation queue as pending; anonymous reports are kept for moderators but do not hide comments.
+// @Tags Comment
+// @Accept json
+// @Produce json
+// @Param id path string true "Comment ID"
+// @Param body body models.ReportCommentPayload true "Why the comment is inappropriate"
+// @Param X-Captcha-Token header string false "Token from /captcha/get-challenge; not needed when logged in"
+// @Param X-Captcha-Solution header string false "Nonce or answer solving the challenge"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /amg/v1/comments/report/{id} [post]
+func (h *CommentHandler) ReportComment(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+	var payload models.ReportCommentPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+	reason := strings.TrimSpace(payload.Reason)
+	if reason == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "reason là bắt buộc"})
+	}
+	if utf8.RuneCountInString(reason) > service.MaxReportReasonLength {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "reason is too long"})
+	}
+	if ok, err := h.canSeeComment(c, id); !ok {
+		return err
+	}
+
+	reporter := "visitor:" + service.VisitorID(c.IP(), c.Get(fiber.HeaderUserAgent))
+	user, member := middleware.CurrentUser(c)
+	if member {
+		reporter = "user:" + user.ID
+	}
+
+	hidden, err := service.ReportComment(h.DB, id, reporter, member, reason)
+	if err != nil {
+		return feedbackError(c, err)
+	}
+	return c.JSON(fiber.Map{"message": "Comment reported", "hidden": hidden})
+}
+
+// GetCommentReports godoc
+// @Summary List the reports on a comment
+// @Description Lists why readers reported a comment, oldest first. Staff only.
+// @Tags Comment
+// @Accept json
+// @Produce json
+// @Param id path string true "Comment ID"
+// @Success 200 {array} models.CommentReport
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /amg/v1/comments/get-reports/{id} [get]
+func (h *CommentHandler) GetCommentReports(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+
+	reports, err := service.CommentReports(h.DB, id)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "DB error"})
+	}
+	return c.JSON(reports)
+}
+
+// canSeeComment reports whether the requester may read the post of the
+// comment, and sends the error response if not.
+func (h *CommentHandler) canSeeComment(c *fiber.Ctx, id primitive.ObjectID) (bool, error) {
+	comment, err := service.FindComment(h.DB, id)
+	if err != nil {
+		return false, feedbackError(c, err)
+	}
+	ok, err := h.canSeePost(c, comment.PostId)
+	if err != nil {
+		return false, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "DB error"})
+	}
+	if !ok {
+		return false, c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Comment not found"})
+	}
+	return true, nil
+}
+
+func feedbackError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, service.ErrCommentNotFound), errors.Is(err, service.ErrCommentNotPublished):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Comment not found"})
+	case errors.Is(err, service.ErrInvalidReaction):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "type must be 'like', 'heart' or 'thanks'"})
+	case errors.Is(err, service.ErrAlreadyReported):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "You have already reported this comment"})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "DB error"})
+}
@@ -0,0 +1,129 @@
+package comment
+
+import (
+	"amg-backend/middleware"
+	"amg-backend/models"
+	"amg-backend/service"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"strings"
+)
+
+// GetModerationQueue godoc
+// @Summary List comments awaiting moderation
+// @Description Lists comments across all posts, oldest first, with the title of their post. Staff only.
+// @Tags Comment
+// @Accept json
+// @Produce json
+// @Param status query string false "Comment status to list: pending (default), rejected or spam"
+// @Param limit query int false "Maximum number of comments (default 50, max 200)"
+// @Success 200 {array} models.ModerationQueueItem
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /amg/v1/comments/moderation-queue [get]
+func (h *CommentHandler) GetModerationQueue(c *fiber.Ctx) error {
+	status := c.Query("status", models.CommentStatusPending)
+	if status != models.CommentStatusPending && status != models.CommentStatusRejected && status != models.CommentStatusSpam {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "status must be 'pending', 'rejected' or 'spam'"})
+	}
+	limit := c.QueryInt("limit", 50)
+	if limit < 1 || limit > 200 {
+		limit = 50
+	}
+
+	items, err := service.ModerationQueue(h.DB, status, int64(limit))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "DB error"})
+	}
+
+	return c.JSON(items)
+}
+
+// ModerateComment godoc
+// @Summary Approve, reject or flag a comment as spam
+// @Description Sets the moderation status of a comment. Approved comments are published; rejected and spam ones are hidden from readers. Reader reports count from zero again afterwards. Staff only.
+// @Tags Comment
+// @Accept json
+// @Produce json
+// @Param id path string true "Comment ID"
+// @Param body body models.ModerateCommentPayload true "Moderation action: approve, reject or spam"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /amg/v1/comments/moderate/{id} [post]
+func (h *CommentHandler) ModerateComment(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+	var payload models.ModerateCommentPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+
+	user, _ := middleware.CurrentUser(c)
+	found, err := service.ModerateComment(h.DB, id, strings.ToLower(payload.Action), user.ID)
+	if err != nil {
+		if errors.Is(err, service.ErrUnknownModerationAction) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "action must be 'approve', 'reject' or 'spam'"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Moderation failed"})
+	}
+	if !found {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Comment not found"})
+	}
+
+	return c.JSON(fiber.Map{"message": "Comment moderated successfully"})
+}
+
+// GetModerationSettings godoc
+// @Summary Get the comment moderation settings
+// @Description Returns the default moderation mode and the per-category overrides. An override with an empty category applies to all categories. Staff only.
+// @Tags Comment
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} map[string]string
+// @Router /amg/v1/comments/moderation-settings [get]
+func (h *CommentHandler) GetModerationSettings(c *fiber.Ctx) error {
+	settings, err := service.ListModerationSettings(h.DB)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "DB error"})
+	}
+	defaultMode, err := service.ModerationModeFor(h.DB, "")
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "DB error"})
+	}
+
+	return c.JSON(fiber.Map{"default": defaultMode, "settings": settings})
+}
+
+// SetModerationSetting godoc
+// @Summary Set the moderation mode of a category
+// @Description Sets 'pre' (hold comments for approval) or 'post' (publish at once) moderation for a category, or for all categories when category is empty. Staff only.
+// @Tags Comment
+// @Accept json
+// @Produce json
+// @Param body body models.CommentModerationSetting true "Category and mode"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /amg/v1/comments/moderation-settings [post]
+func (h *CommentHandler) SetModerationSetting(c *fiber.Ctx) error {
+	var payload models.CommentModerationSetting
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+	mode, ok := service.NormalizeModerationMode(strings.ToLower(payload.Mode))
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "mode must be 'pre' or 'post'"})
+	}
+
+	if err := service.SetModerationMode(h.DB, strings.TrimSpace(payload.Category), mode); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Update failed"})
+	}
+
+	return c.JSON(fiber.Map{"message": "Moderation setting saved"})
+}
@@ -1,6 +1,7 @@
 package comment
 
 import (
+	"amg-backend/middleware"
 	"github.com/gofiber/fiber/v2"
 	"go.mongodb.org/mongo-driver/mongo"
 )
@@ -19,6 +20,15 @@ func RegisterCommentHandler(router fiber.Router, db *mongo.Client) {
 	// Register all endpoints here
 	router.Get("/get-comments-in-post", commentHandler.GetCommentsByPostId)
 	router.Post("/update-comment/:id", commentHandler.UpdateComment)
-	router.Post("/create-comment", commentHandler.CreateComment)
+	router.Post("/create-comment", middleware.RequireCaptcha(db), commentHandler.CreateComment)
 	router.Post("/delete-comment/:id", commentHandler.DeleteComment)
+	router.Get("/get-comment-history/:id", commentHandler.GetCommentHistory)
+	router.Post("/react/:id", commentHandler.ReactToComment)
+	router.Post("/remove-reaction/:id", commentHandler.RemoveCommentReaction)
+	router.Post("/report/:id", middleware.RequireCaptcha(db), commentHandler.ReportComment)
+	router.Get("/get-reports/:id", middleware.RequireStaff, commentHandler.GetCommentReports)
+	router.Get("/moderation-queue", middleware.RequireStaff, commentHandler.GetModerationQueue)
+	router.Post("/moderate/:id", middleware.RequireStaff, commentHandler.ModerateComment)
+	router.Get("/moderation-settings", middleware.RequireStaff, commentHandler.GetModerationSettings)
+	router.Post("/moderation-settings", middleware.RequireStaff, commentHandler.SetModerationSetting)
 }
@@ -0,0 +1,57 @@
+package integrity
+
+import (
+	"amg-backend/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetIntegrityReport godoc
+// @Summary Get the latest content integrity report
+// @Description Returns the last report of missing or untracked /uploads files and internal links to deleted posts, as produced by the nightly check
+// @Tags integrity
+// @Accept json
+// @Produce json
+// @Param type query string false "Only list issues of one type: missing_file, missing_record, pending_record, deleted_post_link or missing_post_link"
+// @Success 200 {object} models.IntegrityReport
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /amg/v1/integrity/get-report [get]
+func (h *IntegrityHandler) GetIntegrityReport(c *fiber.Ctx) error {
+	report, err := service.GetLatestIntegrityReport(h.DB)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "DB error"})
+	}
+	if report == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "No integrity check has run yet"})
+	}
+
+	if issueType := c.Query("type"); issueType != "" {
+		issues := report.Issues[:0]
+		for _, issue := range report.Issues {
+			if issue.Type == issueType {
+				issues = append(issues, issue)
+			}
+		}
+		report.Issues = issues
+	}
+
+	return c.JSON(report)
+}
+
+// RunIntegrityCheck godoc
+// @Summary Run the content integrity check now
+// @Description Scans posts and the landing page immediately instead of waiting for the nightly job, and returns the new report
+// @Tags integrity
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.IntegrityReport
+// @Failure 500 {object} map[string]string
+// @Router /amg/v1/integrity/run-check [post]
+func (h *IntegrityHandler) RunIntegrityCheck(c *fiber.Ctx) error {
+	report, err := service.RunIntegrityCheck(h.DB)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Integrity check failed"})
+	}
+
+	return c.JSON(report)
+}
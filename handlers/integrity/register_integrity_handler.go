@@ -0,0 +1,22 @@
+package integrity
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type IntegrityHandler struct {
+	Router fiber.Router
+	DB     *mongo.Client
+}
+
+func RegisterIntegrityHandler(router fiber.Router, db *mongo.Client) {
+	integrityHandler := IntegrityHandler{
+		Router: router,
+		DB:     db,
+	}
+
+	// Register all endpoints here
+	router.Get("/get-report", integrityHandler.GetIntegrityReport)
+	router.Post("/run-check", integrityHandler.RunIntegrityCheck)
+}
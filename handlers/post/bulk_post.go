@@ -0,0 +1,158 @@
+package post
+
+import (
+	"amg-backend/config"
+	"amg-backend/models"
+	"amg-backend/service"
+	"context"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"strings"
+	"time"
+)
+
+// MaxBulkPosts limits how many posts a single bulk action may touch.
+const MaxBulkPosts = 200
+
+var errPostNotFound = errors.New("post not found")
+
+// BulkPostAction godoc
+// @Summary Apply an action to several posts
+// @Description Applies one action to a list of posts and reports the outcome for each of them. Actions: set_status (status), set_category (category), add_tags (tags), remove_tags (tags), delete, restore. Staff only.
+// @Tags post
+// @Accept json
+// @Produce json
+// @Param body body models.BulkPostActionPayload true "Post IDs and action"
+// @Success 200 {array} models.BulkPostActionResult
+// @Failure 400 {object} map[string]string
+// @Router /amg/v1/posts/bulk-action [post]
+func (h *PostHandler) BulkPostAction(c *fiber.Ctx) error {
+	var payload models.BulkPostActionPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+	if len(payload.PostIDs) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "post_ids is required"})
+	}
+	if len(payload.PostIDs) > MaxBulkPosts {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Too many posts in one request"})
+	}
+
+	var apply func(id primitive.ObjectID) error
+	switch payload.Action {
+	case models.BulkActionSetStatus:
+		if !isEditableStatus(payload.Status) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "status must be 'active' or 'draft'"})
+		}
+		apply = func(id primitive.ObjectID) error {
+			return h.updateLivePost(id, bson.M{"$set": bson.M{"status": payload.Status}})
+		}
+	case models.BulkActionSetCategory:
+		category := strings.TrimSpace(payload.Category)
+		if category == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "category is required"})
+		}
+		apply = func(id primitive.ObjectID) error {
+			return h.updateLivePost(id, bson.M{"$set": bson.M{"category": category}})
+		}
+	case models.BulkActionAddTags, models.BulkActionRemoveTags:
+		tags := parseTags(payload.Tags)
+		if len(tags) == 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "tags is required"})
+		}
+		operator := "$addToSet"
+		value := interface{}(bson.M{"$each": tags})
+		if payload.Action == models.BulkActionRemoveTags {
+			operator = "$pullAll"
+			value = tags
+		}
+		apply = func(id primitive.ObjectID) error {
+			if err := h.normalisePostTags(id); err != nil {
+				return err
+			}
+			return h.updateLivePost(id, bson.M{operator: bson.M{"tags": value}})
+		}
+	case models.BulkActionDelete:
+		apply = func(id primitive.ObjectID) error {
+			found, err := service.MoveToTrash(h.DB, models.TrashTypePost, id)
+			if err == nil && !found {
+				return errPostNotFound
+			}
+			return err
+		}
+	case models.BulkActionRestore:
+		apply = func(id primitive.ObjectID) error {
+			// Related posts are refreshed once for the whole batch below
+			found, err := service.RestoreFromTrashBatch(h.DB, models.TrashTypePost, id)
+			if err == nil && !found {
+				return errors.New("post is not in the trash")
+			}
+			return err
+		}
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Unknown action"})
+	}
+
+	results := make([]models.BulkPostActionResult, 0, len(payload.PostIDs))
+	succeeded := 0
+	for _, idParam := range payload.PostIDs {
+		result := models.BulkPostActionResult{ID: idParam}
+		id, err := primitive.ObjectIDFromHex(idParam)
+		if err != nil {
+			result.Error = "Invalid post ID"
+		} else if err := apply(id); err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Success = true
+			succeeded++
+		}
+		results = append(results, result)
+	}
+
+	if succeeded > 0 {
+		service.RefreshRelatedPostsAsync(h.DB)
+	}
+
+	return c.JSON(results)
+}
+
+// updateLivePost applies an update to a post that is not in the trash.
+// Images keep their 'used' status: active and draft posts both reference them.
+func (h *PostHandler) updateLivePost(id primitive.ObjectID, update bson.M) error {
+	collection := h.DB.Database(config.DBName).Collection("Post")
+
+	if set, ok := update["$set"].(bson.M); ok {
+		set["update_at"] = time.Now()
+	} else {
+		update["$set"] = bson.M{"update_at": time.Now()}
+	}
+
+	result, err := collection.UpdateOne(context.TODO(), bson.M{"_id": id, "status": bson.M{"$ne": models.PostStatusDeleted}}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		count, err := collection.CountDocuments(context.TODO(), bson.M{"_id": id})
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return errPostNotFound
+		}
+		return errors.New("post is in the trash, restore it first")
+	}
+	return nil
+}
+
+// normalisePostTags turns a null tags field, stored for posts saved without
+// tags, into an empty list so that array operators can be applied to it.
+func (h *PostHandler) normalisePostTags(id primitive.ObjectID) error {
+	_, err := h.DB.Database(config.DBName).Collection("Post").UpdateOne(context.TODO(),
+		bson.M{"_id": id, "tags": bson.M{"$type": "null"}},
+		mongo.Pipeline{{{Key: "$set", Value: bson.M{"tags": bson.M{"$ifNull": bson.A{"$tags", bson.A{}}}}}}},
+	)
+	return err
+}
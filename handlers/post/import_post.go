@@ -0,0 +1,47 @@
+package post
+
+import (
+	"amg-backend/service"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"strings"
+)
+
+// ImportWordPress godoc
+// @Summary Import posts from a WordPress export
+// @Description Imports the posts of a WordPress WXR export. Media under wp-content/uploads is copied into /uploads (from WORDPRESS.MEDIA_DIR, or downloaded from the exported site's own host) and image URLs are rewritten. Posts imported before are skipped, so the import can be re-run.
+// @Tags post
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "WXR export (.xml)"
+// @Param category_map formData string false "JSON object mapping WordPress category slugs to categories; unmapped categories fall back to default_category"
+// @Param default_category formData string false "Category for posts without a (mapped) category"
+// @Success 200 {object} models.WordPressImportResult
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /amg/v1/posts/import-wordpress [post]
+func (h *PostHandler) ImportWordPress(c *fiber.Ctx) error {
+	file, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "WXR file is required"})
+	}
+
+	opts := service.WordPressImportOptions{DefaultCategory: strings.TrimSpace(c.FormValue("default_category"))}
+	if raw := strings.TrimSpace(c.FormValue("category_map")); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &opts.CategoryMap); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "category_map must be a JSON object"})
+		}
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Cannot read uploaded file"})
+	}
+	defer src.Close()
+
+	result, err := service.ImportWordPress(h.DB, src, opts)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(result)
+}
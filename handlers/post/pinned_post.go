@@ -0,0 +1,271 @@
+package post
+
+import (
+	"amg-backend/config"
+	"amg-backend/middleware"
+	"amg-backend/models"
+	"amg-backend/service"
+	"context"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"sort"
+	"time"
+)
+
+// sortPinnedFirst moves posts with an active pin to the front, ordered by
+// PinOrder, and keeps the existing order for everything else.
+func sortPinnedFirst(posts []models.Post) {
+	now := time.Now()
+	sort.SliceStable(posts, func(i, j int) bool {
+		pi, pj := posts[i].PinActive(now), posts[j].PinActive(now)
+		if pi != pj {
+			return pi
+		}
+		if pi {
+			return posts[i].PinOrder < posts[j].PinOrder
+		}
+		return false
+	})
+}
+
+// PinPost godoc
+// @Summary Pin a post to the top of its category
+// @Description Pins a post. Without an explicit order the post is placed after the posts already pinned in its category. An optional pinned_until makes the pin expire. Staff only.
+// @Tags post
+// @Accept json
+// @Produce json
+// @Param id path string true "Post ID"
+// @Param body body models.PinPostPayload false "Pin options"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /amg/v1/posts/pin-post/{id} [post]
+func (h *PostHandler) PinPost(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid post ID"})
+	}
+
+	var payload models.PinPostPayload
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&payload); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+		}
+	}
+	if payload.PinnedUntil != nil && !payload.PinnedUntil.After(time.Now()) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "pinned_until must be in the future"})
+	}
+
+	collection := h.DB.Database(config.DBName).Collection("Post")
+
+	var post models.Post
+	if err := collection.FindOne(context.TODO(), bson.M{"_id": id}).Decode(&post); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Post not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "DB error"})
+	}
+
+	order := 1
+	if payload.Order != nil {
+		order = *payload.Order
+	} else {
+		var last models.Post
+		findOptions := options.FindOne().SetSort(bson.D{{Key: "pin_order", Value: -1}})
+		filter := bson.M{"category": post.Category, "is_pinned": true, "_id": bson.M{"$ne": id}}
+		err := collection.FindOne(context.TODO(), filter, findOptions).Decode(&last)
+		if err == nil {
+			order = last.PinOrder + 1
+		} else if !errors.Is(err, mongo.ErrNoDocuments) {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "DB error"})
+		}
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"is_pinned": true,
+			"pin_order": order,
+			"update_at": time.Now(),
+		},
+	}
+	if payload.PinnedUntil != nil {
+		update["$set"].(bson.M)["pinned_until"] = *payload.PinnedUntil
+	} else {
+		update["$unset"] = bson.M{"pinned_until": ""}
+	}
+
+	if _, err := collection.UpdateByID(context.TODO(), id, update); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Pin failed"})
+	}
+
+	return c.JSON(fiber.Map{"message": "Post pinned successfully"})
+}
+
+// UnpinPost godoc
+// @Summary Unpin a post
+// @Description Removes the pin from a post. Staff only.
+// @Tags post
+// @Accept json
+// @Produce json
+// @Param id path string true "Post ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /amg/v1/posts/unpin-post/{id} [post]
+func (h *PostHandler) UnpinPost(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid post ID"})
+	}
+
+	update := bson.M{
+		"$set":   bson.M{"is_pinned": false, "pin_order": 0, "update_at": time.Now()},
+		"$unset": bson.M{"pinned_until": ""},
+	}
+
+	collection := h.DB.Database(config.DBName).Collection("Post")
+	result, err := collection.UpdateByID(context.TODO(), id, update)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Unpin failed"})
+	}
+	if result.MatchedCount == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Post not found"})
+	}
+
+	return c.JSON(fiber.Map{"message": "Post unpinned successfully"})
+}
+
+// ReorderPinnedPosts godoc
+// @Summary Reorder the pinned posts of a category
+// @Description Sets the pin order of the given posts to their position in the list. Every post must be pinned and belong to the category. Staff only.
+// @Tags post
+// @Accept json
+// @Produce json
+// @Param category path string true "Post Category"
+// @Param body body models.ReorderPinnedPostsPayload true "Pinned post IDs in display order"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /amg/v1/posts/reorder-pinned-posts/{category} [post]
+func (h *PostHandler) ReorderPinnedPosts(c *fiber.Ctx) error {
+	category := c.Params("category")
+	if category == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid category"})
+	}
+
+	var payload models.ReorderPinnedPostsPayload
+	if err := c.BodyParser(&payload); err != nil || len(payload.PostIDs) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "post_ids is required"})
+	}
+
+	ids := make([]primitive.ObjectID, len(payload.PostIDs))
+	for i, idParam := range payload.PostIDs {
+		id, err := primitive.ObjectIDFromHex(idParam)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid post ID: " + idParam})
+		}
+		ids[i] = id
+	}
+
+	collection := h.DB.Database(config.DBName).Collection("Post")
+	count, err := collection.CountDocuments(context.TODO(), bson.M{
+		"_id":       bson.M{"$in": ids},
+		"category":  category,
+		"is_pinned": true,
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "DB error"})
+	}
+	if int(count) != len(ids) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "All posts must be pinned and belong to the category"})
+	}
+
+	now := time.Now()
+	for i, id := range ids {
+		update := bson.M{"$set": bson.M{"pin_order": i + 1, "update_at": now}}
+		if _, err := collection.UpdateByID(context.TODO(), id, update); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Reorder failed"})
+		}
+	}
+
+	return c.JSON(fiber.Map{"message": "Pinned posts reordered successfully"})
+}
+
+// SetFeaturedPost godoc
+// @Summary Mark or unmark a post as featured
+// @Description Sets the featured flag of a post. Staff only.
+// @Tags post
+// @Accept json
+// @Produce json
+// @Param id path string true "Post ID"
+// @Param body body models.FeaturePostPayload true "Featured flag"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /amg/v1/posts/set-featured/{id} [post]
+func (h *PostHandler) SetFeaturedPost(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid post ID"})
+	}
+
+	var payload models.FeaturePostPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+
+	collection := h.DB.Database(config.DBName).Collection("Post")
+	update := bson.M{"$set": bson.M{"is_featured": payload.Featured, "update_at": time.Now()}}
+	result, err := collection.UpdateByID(context.TODO(), id, update)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Update failed"})
+	}
+	if result.MatchedCount == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Post not found"})
+	}
+
+	return c.JSON(fiber.Map{"message": "Post updated successfully"})
+}
+
+// GetFeaturedPosts godoc
+// @Summary Get featured posts
+// @Description Retrieves active featured posts, optionally limited to one category. Pinned posts come first.
+// @Tags post
+// @Accept json
+// @Produce json
+// @Param category query string false "Post Category"
+// @Param light query bool false "Leave out the content (for list views)"
+// @Success 200 {array} models.Post
+// @Failure 500 {object} map[string]string
+// @Router /amg/v1/posts/get-featured-posts [get]
+func (h *PostHandler) GetFeaturedPosts(c *fiber.Ctx) error {
+	filter := bson.M{"is_featured": true, "status": models.PostStatusActive}
+	if category := c.Query("category"); category != "" {
+		filter["category"] = category
+	}
+
+	service.ApplyVisibilityFilter(filter, middleware.PostViewer(c, h.DB))
+
+	collection := h.DB.Database(config.DBName).Collection("Post")
+	findOptions := listFindOptions(c).SetSort(bson.D{{Key: "create_at", Value: -1}})
+	cursor, err := collection.Find(context.TODO(), filter, findOptions)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "DB error"})
+	}
+	defer cursor.Close(context.TODO())
+
+	posts := make([]models.Post, 0)
+	if err := cursor.All(context.TODO(), &posts); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to decode posts"})
+	}
+	sortPinnedFirst(posts)
+
+	return c.JSON(posts)
+}
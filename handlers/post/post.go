@@ -2,7 +2,9 @@ package post
 
 import (
 	"amg-backend/config"
+	"amg-backend/middleware"
 	"amg-backend/models"
+	"amg-backend/service"
 	"context"
 	"errors"
 	"fmt"
@@ -13,35 +15,33 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"log"
+	"mime/multipart"
 	"os"
 	"path/filepath"
-	"regexp"
+	"strings"
 	"time"
 )
 
-func extractImageUrls(content string) []string {
-	re := regexp.MustCompile(`src="(/uploads/[^"]+)"`)
-	matches := re.FindAllStringSubmatch(content, -1)
-	urls := make([]string, len(matches))
-	for i, match := range matches {
-		urls[i] = match[1]
-	}
-	return urls
-}
-
 // GetAllPosts godoc
 // @Summary Get all posts
-// @Description Retrieves all posts from the database
+// @Description Retrieves all posts from the database. Visitors who are not staff only get active posts.
 // @Tags post
 // @Accept json
 // @Produce json
+// @Param light query bool false "Leave out the content (for list views)"
 // @Success 200 {array} models.Post
 // @Failure 500 {object} map[string]string
 // @Router /amg/v1/posts/get-all-posts [get]
 func (h *PostHandler) GetAllPosts(c *fiber.Ctx) error {
 	var posts []models.Post
 	collection := h.DB.Database(config.DBName).Collection("Post")
-	cursor, err := collection.Find(context.TODO(), bson.M{})
+	filter := bson.M{}
+	viewer := middleware.PostViewer(c, h.DB)
+	if !viewer.Staff {
+		filter["status"] = models.PostStatusActive
+	}
+	service.ApplyVisibilityFilter(filter, viewer)
+	cursor, err := collection.Find(context.TODO(), filter, listFindOptions(c))
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "DB error"})
 	}
@@ -56,13 +56,14 @@ func (h *PostHandler) GetAllPosts(c *fiber.Ctx) error {
 
 // GetPostById godoc
 // @Summary Get a single post by ID with associated images
-// @Description Retrieves a post by its ID
+// @Description Retrieves a post by its ID and counts a view (once per visitor per day, bots excluded). Posts that are not active are only returned to staff; members and class restricted posts need a matching logged-in user.
 // @Tags post
 // @Accept json
 // @Produce json
 // @Param id path string true "Post ID"
 // @Success 200 {object} models.PostDetailResponse
 // @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
 // @Failure 404 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /amg/v1/posts/get-post/{id} [get]
@@ -84,8 +85,33 @@ func (h *PostHandler) GetPostById(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "DB error"})
 	}
 
-	re := regexp.MustCompile(regexp.QuoteMeta(config.BaseURL) + `/uploads/[^"]+`)
-	imageUrls := re.FindAllString(post.Content, -1)
+	viewer := middleware.PostViewer(c, h.DB)
+	if post.Status != models.PostStatusActive && !viewer.Staff {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Post not found"})
+	}
+	if !service.CanViewPost(viewer, post) {
+		if !viewer.LoggedIn {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Login required to read this post"})
+		}
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Post not found"})
+	}
+
+	if post.Status == models.PostStatusActive {
+		counted, err := service.RecordPostView(h.DB, post.ID, c.IP(), c.Get(fiber.HeaderUserAgent))
+		if err != nil {
+			log.Printf("Warning: could not record view for post %s: %v\n", post.ID.Hex(), err)
+		}
+		if counted {
+			post.ViewCount++
+		}
+	}
+
+	return c.JSON(h.postDetail(post))
+}
+
+// postDetail loads the images and attachments referenced by a post.
+func (h *PostHandler) postDetail(post models.Post) models.PostDetailResponse {
+	imageUrls := service.ExtractImageUrls(post.Content)
 
 	var relatedImages []models.UploadedImage
 	if len(imageUrls) > 0 {
@@ -99,22 +125,28 @@ func (h *PostHandler) GetPostById(c *fiber.Ctx) error {
 		}
 	}
 
-	response := models.PostDetailResponse{
-		Post:   post,
-		Images: relatedImages,
+	attachments, err := service.GetPostAttachments(h.DB, post.ID)
+	if err != nil {
+		log.Printf("Warning: could not load attachments for post %s: %v\n", post.ID.Hex(), err)
+		attachments = make([]models.Attachment, 0)
 	}
 
-	return c.JSON(response)
+	return models.PostDetailResponse{
+		Post:        post,
+		Images:      relatedImages,
+		Attachments: attachments,
+	}
 }
 
 // GetPostsByCategory godoc
 // @Summary Get posts by category
-// @Description Retrieves posts by category
+// @Description Retrieves posts by category, pinned posts first
 // @Tags post
 // @Accept json
 // @Produce json
 // @Param category path string true "Post Category"
-// @Param status query string false "Filter by post-status (e.g., 'published', 'draft')"
+// @Param status query string false "Filter by post-status (e.g., 'active', 'draft'); ignored for visitors who are not staff"
+// @Param light query bool false "Leave out the content (for list views)"
 // @Success 200 {array} models.Post
 // @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
@@ -131,9 +163,14 @@ func (h *PostHandler) GetPostsByCategory(c *fiber.Ctx) error {
 	if status != "" {
 		filter["status"] = status
 	}
+	viewer := middleware.PostViewer(c, h.DB)
+	if !viewer.Staff {
+		filter["status"] = models.PostStatusActive
+	}
+	service.ApplyVisibilityFilter(filter, viewer)
 	var posts []models.Post
 	collection := h.DB.Database(config.DBName).Collection("Post")
-	findOptions := options.Find()
+	findOptions := listFindOptions(c)
 	findOptions.SetSort(bson.D{{Key: "create_at", Value: -1}})
 	cursor, err := collection.Find(context.TODO(), filter, findOptions)
 	if err != nil {
@@ -148,17 +185,19 @@ func (h *PostHandler) GetPostsByCategory(c *fiber.Ctx) error {
 	if posts == nil {
 		posts = make([]models.Post, 0)
 	}
+	sortPinnedFirst(posts)
 
 	return c.JSON(posts)
 }
 
 // GetPostsByStatus godoc
 // @Summary Get posts by status
-// @Description Retrieves posts by status
+// @Description Retrieves posts by status. Only active posts are returned to visitors who are not staff.
 // @Tags post
 // @Accept json
 // @Produce json
 // @Param status path string true "Post Status"
+// @Param light query bool false "Leave out the content (for list views)"
 // @Success 200 {array} models.Post
 // @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
@@ -169,9 +208,15 @@ func (h *PostHandler) GetPostsByStatus(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid status"})
 	}
 
+	viewer := middleware.PostViewer(c, h.DB)
+	if status != models.PostStatusActive && !viewer.Staff {
+		return c.JSON(make([]models.Post, 0))
+	}
+
 	var posts []models.Post
 	collection := h.DB.Database(config.DBName).Collection("Post")
-	cursor, err := collection.Find(context.TODO(), bson.M{"status": status})
+	filter := service.ApplyVisibilityFilter(bson.M{"status": status}, viewer)
+	cursor, err := collection.Find(context.TODO(), filter, listFindOptions(c))
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "DB error"})
 	}
@@ -189,9 +234,68 @@ func (h *PostHandler) GetPostsByStatus(c *fiber.Ctx) error {
 	return c.JSON(posts)
 }
 
+// GetPopularPosts godoc
+// @Summary Get the most read posts
+// @Description Retrieves the public active posts with the most views over the last N days
+// @Tags post
+// @Accept json
+// @Produce json
+// @Param days query int false "Number of days to look back (default 7, max 90)"
+// @Param limit query int false "Maximum number of posts (default 5, max 20)"
+// @Success 200 {array} models.PopularPost
+// @Failure 500 {object} map[string]string
+// @Router /amg/v1/posts/get-popular-posts [get]
+func (h *PostHandler) GetPopularPosts(c *fiber.Ctx) error {
+	days := c.QueryInt("days", 7)
+	if days < 1 || days > 90 {
+		days = 7
+	}
+	limit := c.QueryInt("limit", 5)
+	if limit < 1 || limit > 20 {
+		limit = 5
+	}
+
+	posts, err := service.GetPopularPosts(h.DB, days, limit)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "DB error"})
+	}
+
+	return c.JSON(posts)
+}
+
+// GetRelatedPosts godoc
+// @Summary Get related posts
+// @Description Retrieves "you may also like" suggestions for a post, based on shared category, tags and text similarity. Only public posts are suggested. Suggestions are computed in the background, so a new or just-edited post may have none for a short while.
+// @Tags post
+// @Accept json
+// @Produce json
+// @Param id path string true "Post ID"
+// @Param limit query int false "Maximum number of posts (default 4, max 8)"
+// @Success 200 {array} models.Post
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /amg/v1/posts/get-related-posts/{id} [get]
+func (h *PostHandler) GetRelatedPosts(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid post ID"})
+	}
+	limit := c.QueryInt("limit", 4)
+	if limit < 1 || limit > service.MaxRelatedPosts {
+		limit = 4
+	}
+
+	posts, err := service.GetRelatedPosts(h.DB, id, limit)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "DB error"})
+	}
+
+	return c.JSON(posts)
+}
+
 // GetSinglePostByCategory godoc
 // @Summary Get a single post by category
-// @Description Retrieves a single post by category
+// @Description Retrieves the top pinned post of a category, otherwise the newest featured post, otherwise the newest active post
 // @Tags post
 // @Accept json
 // @Produce json
@@ -207,22 +311,41 @@ func (h *PostHandler) GetSinglePostByCategory(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid category"})
 	}
 
-	var post models.Post
+	var posts []models.Post
 	collection := h.DB.Database(config.DBName).Collection("Post")
-	err := collection.FindOne(context.TODO(), bson.M{"category": category}).Decode(&post)
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "create_at", Value: -1}})
+	filter := service.ApplyVisibilityFilter(bson.M{"category": category, "status": models.PostStatusActive}, middleware.PostViewer(c, h.DB))
+	cursor, err := collection.Find(context.TODO(), filter, findOptions)
 	if err != nil {
-		if errors.Is(err, mongo.ErrNoDocuments) {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Post not found"})
-		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "DB error"})
 	}
+	defer cursor.Close(context.TODO())
+
+	if err := cursor.All(context.TODO(), &posts); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to decode posts"})
+	}
+	if len(posts) == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Post not found"})
+	}
+
+	// Pinned posts win, then the newest featured post, then the newest post
+	sortPinnedFirst(posts)
+	if posts[0].PinActive(time.Now()) {
+		return c.JSON(posts[0])
+	}
+	for _, post := range posts {
+		if post.IsFeatured {
+			return c.JSON(post)
+		}
+	}
 
-	return c.JSON(post)
+	return c.JSON(posts[0])
 }
 
 // UpdatePost godoc
 // @Summary Update a post
-// @Description Updates a post by its ID
+// @Description Updates a post by its ID. Attachments in attachment_ids that do not exist or belong to another post are not linked and are listed in skipped_attachment_ids. Staff only.
 // @Tags post
 // @Accept multipart/form-data
 // @Produce json
@@ -254,9 +377,21 @@ func (h *PostHandler) UpdatePost(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "DB error finding old post"})
 	}
 
-	oldImageUrls := extractImageUrls(oldPost.Content)
-	newContent := form.Value["content"][0]
-	newImageUrls := extractImageUrls(newContent)
+	format := oldPost.ContentFormat
+	if values, ok := form.Value["content_format"]; ok && len(values) > 0 && values[0] != "" {
+		format = values[0]
+	}
+	format, ok := service.NormalizeContentFormat(format)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "content_format must be 'html' or 'markdown'"})
+	}
+
+	oldImageUrls := service.ExtractImageUrls(oldPost.Content)
+	newContent, newSource, err := h.prepareContent(form.Value["content"][0], format)
+	if err != nil {
+		return inlineImageError(c, err)
+	}
+	newImageUrls := service.ExtractImageUrls(newContent)
 
 	oldUrlSet := make(map[string]bool)
 	for _, url := range oldImageUrls {
@@ -284,17 +419,14 @@ func (h *PostHandler) UpdatePost(c *fiber.Ctx) error {
 		}
 	}
 
-	if len(newImageUrls) > 0 {
-		filter := bson.M{"url": bson.M{"$in": newImageUrls}}
-		update := bson.M{"$set": bson.M{"status": models.ImageStatusUsed}}
-		_, err := imageCollection.UpdateMany(context.TODO(), filter, update)
-		if err != nil {
-			fmt.Printf("Warning: could not update current images to used: %v\n", err)
-		}
+	if err := service.MarkImagesAsUsed(h.DB, newContent); err != nil {
+		fmt.Printf("Warning: could not update current images to used: %v\n", err)
 	}
 
 	updateData := bson.M{}
 	updateData["content"] = newContent
+	updateData["content_format"] = format
+	updateData["content_source"] = newSource
 	if titles, ok := form.Value["title"]; ok && len(titles) > 0 {
 		updateData["title"] = titles[0]
 	}
@@ -304,6 +436,26 @@ func (h *PostHandler) UpdatePost(c *fiber.Ctx) error {
 	if authors, ok := form.Value["author"]; ok && len(authors) > 0 {
 		updateData["author"] = authors[0]
 	}
+	if tags, ok := form.Value["tags"]; ok {
+		updateData["tags"] = parseTags(tags)
+	}
+	if statuses, ok := form.Value["status"]; ok && len(statuses) > 0 && statuses[0] != "" {
+		if !isEditableStatus(statuses[0]) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "status must be 'active' or 'draft'"})
+		}
+		updateData["status"] = statuses[0]
+	}
+
+	_, hasVisibility := form.Value["visibility"]
+	_, hasClasses := form.Value["visible_to_classes"]
+	if hasVisibility || hasClasses {
+		visibility, classes, ok := visibilityFromForm(form, oldPost.Visibility, oldPost.VisibleToClasses)
+		if !ok {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "visibility must be 'public', 'members' or 'classes' (with visible_to_classes)"})
+		}
+		updateData["visibility"] = visibility
+		updateData["visible_to_classes"] = classes
+	}
 
 	file, err := c.FormFile("header_image")
 	if err == nil && file != nil {
@@ -322,26 +474,67 @@ func (h *PostHandler) UpdatePost(c *fiber.Ctx) error {
 		updateData["header_image"] = fmt.Sprintf("/uploads/%s", uniqueFilename)
 	}
 
+	merged := oldPost
+	merged.Content = newContent
+	merged.ContentFormat = format
+	merged.ContentSource = newSource
+	if title, ok := updateData["title"].(string); ok {
+		merged.Title = title
+	}
+	if headerImage, ok := updateData["header_image"].(string); ok {
+		merged.HeaderImage = headerImage
+	}
+	applySummaryOverrides(&merged, form)
+	service.ApplyPostSummary(&merged)
+	for key, value := range service.PostSummaryFields(merged) {
+		updateData[key] = value
+	}
+
 	updateData["update_at"] = time.Now()
 
 	_, err = postCollection.UpdateByID(context.TODO(), id, bson.M{"$set": updateData})
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Update failed in database"})
 	}
+	var skipped []primitive.ObjectID
+	if values, ok := form.Value["attachment_ids"]; ok {
+		attachmentIDs, err := parseObjectIDs(values)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid attachment ID"})
+		}
+		skipped, err = service.LinkAttachments(h.DB, id, attachmentIDs)
+		if err != nil {
+			fmt.Printf("Warning: could not link attachments: %v\n", err)
+		}
+	}
+	service.RefreshRelatedPostsAsync(h.DB)
 
+	if len(skipped) > 0 {
+		return c.JSON(fiber.Map{"message": "Post updated successfully", "skipped_attachment_ids": skipped})
+	}
 	return c.JSON(fiber.Map{"message": "Post updated successfully"})
 }
 
 // CreatePost godoc
 // @Summary Create a new post
-// @Description Creates a new post. The content should contain full URLs to images previously uploaded.
+// @Description Creates a new post. The content should contain full URLs to images previously uploaded; pasted base64 images are extracted into /uploads. Staff only.
 // @Tags post
 // @Accept multipart/form-data
 // @Produce json
 // @Param title formData string true "Post Title"
-// @Param content formData string true "Post Content"
+// @Param content formData string true "Post Content (HTML, or Markdown source when content_format is 'markdown')"
+// @Param content_format formData string false "Content format: 'html' (default) or 'markdown'"
 // @Param category formData string true "Post Category"
 // @Param author formData string true "Post Author"
+// @Param tags formData []string false "Post Tags (repeated or comma separated)"
+// @Param status formData string false "Post Status: 'active' (default) or 'draft'"
+// @Param attachment_ids formData []string false "IDs of uploaded attachments (repeated or comma separated)"
+// @Param visibility formData string false "Who may read the post: 'public' (default), 'members' or 'classes'"
+// @Param visible_to_classes formData []string false "Classes allowed to read the post when visibility is 'classes' (repeated or comma separated)"
+// @Param excerpt formData string false "Excerpt, generated from the content when empty"
+// @Param og_title formData string false "Open Graph title override"
+// @Param og_description formData string false "Open Graph description override"
+// @Param og_image formData string false "Open Graph image override"
 // @Param headerImage formData file false "Header Image"
 // @Success 200 {object} models.Post
 // @Failure 400 {object} map[string]string
@@ -353,11 +546,40 @@ func (h *PostHandler) CreatePost(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Cannot parse form"})
 	}
 
+	attachmentIDs, err := parseObjectIDs(form.Value["attachment_ids"])
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid attachment ID"})
+	}
+
 	title := form.Value["title"][0]
-	content := form.Value["content"][0]
+	var requestedFormat string
+	if values, ok := form.Value["content_format"]; ok && len(values) > 0 {
+		requestedFormat = values[0]
+	}
+	format, ok := service.NormalizeContentFormat(requestedFormat)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "content_format must be 'html' or 'markdown'"})
+	}
+	content, source, err := h.prepareContent(form.Value["content"][0], format)
+	if err != nil {
+		return inlineImageError(c, err)
+	}
 	category := form.Value["category"][0]
 	author := form.Value["author"][0]
 
+	status := models.PostStatusActive
+	if values, ok := form.Value["status"]; ok && len(values) > 0 && values[0] != "" {
+		if !isEditableStatus(values[0]) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "status must be 'active' or 'draft'"})
+		}
+		status = values[0]
+	}
+
+	visibility, classes, ok := visibilityFromForm(form, "", nil)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "visibility must be 'public', 'members' or 'classes' (with visible_to_classes)"})
+	}
+
 	var headerImagePath string
 	file, err := c.FormFile("header_image")
 	if err == nil && file != nil {
@@ -370,111 +592,255 @@ func (h *PostHandler) CreatePost(c *fiber.Ctx) error {
 	}
 
 	post := models.Post{
-		ID:          primitive.NewObjectID(),
-		Title:       title,
-		Content:     content,
-		Category:    category,
-		Author:      author,
-		HeaderImage: headerImagePath,
-		CreateAt:    time.Now(),
-		UpdateAt:    time.Now(),
-		Status:      "active",
-	}
+		ID:               primitive.NewObjectID(),
+		Title:            title,
+		Content:          content,
+		ContentFormat:    format,
+		ContentSource:    source,
+		Category:         category,
+		Tags:             parseTags(form.Value["tags"]),
+		Author:           author,
+		HeaderImage:      headerImagePath,
+		CreateAt:         time.Now(),
+		UpdateAt:         time.Now(),
+		Status:           status,
+		Visibility:       visibility,
+		VisibleToClasses: classes,
+	}
+	applySummaryOverrides(&post, form)
+	service.ApplyPostSummary(&post)
 
 	postCollection := h.DB.Database(config.DBName).Collection("Post")
-	imageCollection := h.DB.Database(config.DBName).Collection("UploadedImage")
 
-	imageUrlsInContent := extractImageUrls(content)
-
-	if len(imageUrlsInContent) > 0 {
-		filter := bson.M{"url": bson.M{"$in": imageUrlsInContent}}
-		update := bson.M{"$set": bson.M{"status": models.ImageStatusUsed}}
-		_, err := imageCollection.UpdateMany(context.TODO(), filter, update)
-		if err != nil {
-			fmt.Printf("Warning: could not update image statuses on create: %v\n", err)
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update image statuses"})
-		}
+	if err := service.MarkImagesAsUsed(h.DB, content); err != nil {
+		fmt.Printf("Warning: could not update image statuses on create: %v\n", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update image statuses"})
 	}
 
 	_, err = postCollection.InsertOne(context.TODO(), &post)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create post"})
 	}
+	if len(attachmentIDs) > 0 {
+		skipped, err := service.LinkAttachments(h.DB, post.ID, attachmentIDs)
+		if err != nil {
+			fmt.Printf("Warning: could not link attachments on create: %v\n", err)
+		}
+		if len(skipped) > 0 {
+			fmt.Printf("Warning: attachments missing or linked to another post were not linked to post %s: %v\n", post.ID.Hex(), skipped)
+		}
+	}
+	service.RefreshRelatedPostsAsync(h.DB)
 
 	return c.Status(fiber.StatusCreated).JSON(post)
 }
 
+// RegeneratePostSummaries godoc
+// @Summary Regenerate post summaries
+// @Description Recomputes the excerpt, word count, reading time and Open Graph metadata of every post. Staff only.
+// @Tags post
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} map[string]string
+// @Router /amg/v1/posts/regenerate-summaries [post]
+func (h *PostHandler) RegeneratePostSummaries(c *fiber.Ctx) error {
+	updated, err := service.RegeneratePostSummaries(h.DB)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to regenerate summaries"})
+	}
+	return c.JSON(fiber.Map{"message": "Summaries regenerated", "updated": updated})
+}
+
 // DeletePost godoc
 // @Summary Delete a post
-// @Description Deletes a post by its ID
+// @Description Moves a post to the trash. It is purged permanently after the retention period. Staff only.
 // @Tags post
 // @Accept json
 // @Produce json
 // @Param id path string true "Post ID"
 // @Success 200 {object} map[string]string
 // @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /amg/v1/posts/delete-post/{id} [post]
 func (h *PostHandler) DeletePost(c *fiber.Ctx) error {
 	idParam := c.Params("id")
-	id, _ := primitive.ObjectIDFromHex(idParam)
-
-	postCollection := h.DB.Database(config.DBName).Collection("Post")
-	imageCollection := h.DB.Database(config.DBName).Collection("UploadedImage")
-
-	var postToDelete models.Post
-	if err := postCollection.FindOne(context.TODO(), bson.M{"_id": id}).Decode(&postToDelete); err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Post not found"})
-	}
-
-	imageUrlsInContent := extractImageUrls(postToDelete.Content)
-	if len(imageUrlsInContent) > 0 {
-		filter := bson.M{"url": bson.M{"$in": imageUrlsInContent}}
-		update := bson.M{"$set": bson.M{"status": models.ImageStatusPending}}
-		_, err := imageCollection.UpdateMany(context.TODO(), filter, update)
-		if err != nil {
-			fmt.Printf("Warning: could not revert image statuses on delete: %v\n", err)
-		}
-	}
-
-	updateData := bson.M{
-		"$set": bson.M{
-			"update_at": time.Now(),
-			"status":    "deleted",
-		},
+	id, err := primitive.ObjectIDFromHex(idParam)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid post ID format"})
 	}
 
-	_, err := postCollection.UpdateByID(context.TODO(), id, updateData)
+	// Images and attachments stay 'used' while the post is in the trash; they
+	// are released when the post is purged
+	found, err := service.MoveToTrash(h.DB, models.TrashTypePost, id)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "delete failed"})
 	}
+	if !found {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Post not found"})
+	}
+	service.RefreshRelatedPostsAsync(h.DB)
 
 	return c.JSON(fiber.Map{"message": "deleted"})
 }
 
 // RecoveryPost godoc
 // @Summary Recover a deleted post
-// @Description Recovers a post by its ID
+// @Description Restores a post from the trash to the status it had before deletion. Staff only.
 // @Tags post
 // @Accept json
 // @Produce json
 // @Param id path string true "Post ID"
 // @Success 200 {object} map[string]string
 // @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /amg/v1/posts/recovery-post/{id} [post]
 func (h *PostHandler) RecoveryPost(c *fiber.Ctx) error {
 	idParam := c.Params("id")
-	id, _ := primitive.ObjectIDFromHex(idParam)
-
-	var updateData bson.M
-	updateData["update_at"] = time.Now()
-	updateData["status"] = "active"
+	id, err := primitive.ObjectIDFromHex(idParam)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid post ID format"})
+	}
 
-	collection := h.DB.Database(config.DBName).Collection("Post")
-	_, err := collection.UpdateByID(context.TODO(), id, bson.M{"$set": updateData})
+	found, err := service.RestoreFromTrash(h.DB, models.TrashTypePost, id)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "recovery failed"})
 	}
+	if !found {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Deleted post not found"})
+	}
 	return c.JSON(fiber.Map{"message": "recovered"})
 }
+
+// inlineImageError maps errors from processing pasted base64 images to a response.
+func inlineImageError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, service.ErrInlineImageTooLarge) {
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+			"error": fmt.Sprintf("Inline image exceeds the %d MB limit", service.MaxInlineImageSize/(1024*1024)),
+		})
+	}
+	if errors.Is(err, service.ErrTooManyInlineImages) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Content may contain at most %d inline images", service.MaxInlineImagesPerPost),
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to process content images"})
+}
+
+// parseTags accepts repeated form values and comma separated lists, and
+// returns the distinct, trimmed, lower-case tags.
+func parseTags(values []string) []string {
+	seen := make(map[string]bool)
+	tags := make([]string, 0)
+	for _, value := range values {
+		for _, tag := range strings.Split(value, ",") {
+			tag = strings.ToLower(strings.TrimSpace(tag))
+			if tag == "" || seen[tag] {
+				continue
+			}
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// applySummaryOverrides copies the editor supplied excerpt and Open Graph
+// values from the form. A field sent empty clears its override.
+func applySummaryOverrides(post *models.Post, form *multipart.Form) {
+	if values, ok := form.Value["excerpt"]; ok && len(values) > 0 {
+		post.ExcerptOverride = strings.TrimSpace(values[0])
+	}
+	if values, ok := form.Value["og_title"]; ok && len(values) > 0 {
+		post.MetaOverrides.Title = strings.TrimSpace(values[0])
+	}
+	if values, ok := form.Value["og_description"]; ok && len(values) > 0 {
+		post.MetaOverrides.Description = strings.TrimSpace(values[0])
+	}
+	if values, ok := form.Value["og_image"]; ok && len(values) > 0 {
+		post.MetaOverrides.Image = strings.TrimSpace(values[0])
+	}
+}
+
+// prepareContent extracts pasted base64 images from the submitted content and,
+// for Markdown, renders it to sanitised HTML. It returns the HTML stored in
+// Post.Content and the Markdown source (empty for HTML posts).
+func (h *PostHandler) prepareContent(raw string, format string) (string, string, error) {
+	processed, err := service.ProcessAndRecordContentImages(h.DB, raw, format)
+	if err != nil {
+		return "", "", err
+	}
+	if format != models.ContentFormatMarkdown {
+		return processed, "", nil
+	}
+	rendered, err := service.RenderMarkdown(processed)
+	if err != nil {
+		return "", "", err
+	}
+	return rendered, processed, nil
+}
+
+// listFindOptions leaves the content out when the client only renders cards (?light=true).
+func listFindOptions(c *fiber.Ctx) *options.FindOptions {
+	findOptions := options.Find()
+	if c.QueryBool("light") {
+		findOptions.SetProjection(bson.M{"content": 0, "content_source": 0})
+	}
+	return findOptions
+}
+
+// parseObjectIDs accepts repeated form values and comma separated lists of hex IDs.
+func parseObjectIDs(values []string) ([]primitive.ObjectID, error) {
+	ids := make([]primitive.ObjectID, 0)
+	for _, value := range values {
+		for _, hex := range strings.Split(value, ",") {
+			hex = strings.TrimSpace(hex)
+			if hex == "" {
+				continue
+			}
+			id, err := primitive.ObjectIDFromHex(hex)
+			if err != nil {
+				return nil, err
+			}
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// visibilityFromForm reads visibility and visible_to_classes from the form,
+// falling back to the current values for fields that were not sent.
+func visibilityFromForm(form *multipart.Form, visibility string, classes []string) (string, []string, bool) {
+	if values, ok := form.Value["visibility"]; ok && len(values) > 0 {
+		visibility = values[0]
+	}
+	if values, ok := form.Value["visible_to_classes"]; ok {
+		classes = parseClasses(values)
+	}
+	return service.NormalizeVisibility(visibility, classes)
+}
+
+// parseClasses accepts repeated form values and comma separated lists, and
+// returns the distinct, trimmed class names.
+func parseClasses(values []string) []string {
+	seen := make(map[string]bool)
+	classes := make([]string, 0)
+	for _, value := range values {
+		for _, class := range strings.Split(value, ",") {
+			class = strings.TrimSpace(class)
+			if class == "" || seen[class] {
+				continue
+			}
+			seen[class] = true
+			classes = append(classes, class)
+		}
+	}
+	return classes
+}
+
+// isEditableStatus reports whether a status may be set through create/update.
+func isEditableStatus(status string) bool {
+	return status == models.PostStatusActive || status == models.PostStatusDraft
+}
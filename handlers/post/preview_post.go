@@ -0,0 +1,150 @@
+package post
+
+import (
+	"amg-backend/config"
+	"amg-backend/models"
+	"amg-backend/service"
+	"context"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"net/url"
+	"time"
+)
+
+// CreatePreviewLink godoc
+// @Summary Create a preview link for a post
+// @Description Issues a signed, expiring token that lets anyone holding it read the post, whatever its status, without logging in. Staff only.
+// @Tags post
+// @Accept json
+// @Produce json
+// @Param id path string true "Post ID"
+// @Param body body models.CreatePreviewLinkPayload false "Validity in hours (default 72, max 336)"
+// @Success 200 {object} models.PreviewLinkResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /amg/v1/posts/create-preview-link/{id} [post]
+func (h *PostHandler) CreatePreviewLink(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid post ID"})
+	}
+
+	var payload models.CreatePreviewLinkPayload
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&payload); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+		}
+	}
+	ttl := service.DefaultPreviewTTL
+	if payload.ExpiresInHours > 0 {
+		ttl = time.Duration(payload.ExpiresInHours) * time.Hour
+	}
+	if ttl > service.MaxPreviewTTL {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "expires_in_hours is too large"})
+	}
+
+	var post models.Post
+	collection := h.DB.Database(config.DBName).Collection("Post")
+	if err := collection.FindOne(context.TODO(), bson.M{"_id": id}).Decode(&post); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Post not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "DB error"})
+	}
+
+	expiresAt := time.Now().Add(ttl)
+	token := service.GeneratePreviewToken(post.ID, post.PreviewVersion, expiresAt)
+
+	return c.JSON(models.PreviewLinkResponse{
+		Token:     token,
+		URL:       "/amg/v1/posts/preview/" + post.ID.Hex() + "?token=" + token,
+		ExpiresAt: expiresAt,
+	})
+}
+
+// RevokePreviewLinks godoc
+// @Summary Revoke the preview links of a post
+// @Description Invalidates every preview link issued so far for the post. Staff only.
+// @Tags post
+// @Accept json
+// @Produce json
+// @Param id path string true "Post ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /amg/v1/posts/revoke-preview-links/{id} [post]
+func (h *PostHandler) RevokePreviewLinks(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid post ID"})
+	}
+
+	collection := h.DB.Database(config.DBName).Collection("Post")
+	result, err := collection.UpdateByID(context.TODO(), id, bson.M{"$inc": bson.M{"preview_version": 1}})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Revoke failed"})
+	}
+	if result.MatchedCount == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Post not found"})
+	}
+
+	return c.JSON(fiber.Map{"message": "Preview links revoked"})
+}
+
+// GetPostPreview godoc
+// @Summary Preview a post
+// @Description Retrieves a post with its images through a preview link, whatever its status. Attachment URLs carry the preview token so that they download while the post is unpublished. No login required.
+// @Tags post
+// @Accept json
+// @Produce json
+// @Param id path string true "Post ID"
+// @Param token query string true "Preview token"
+// @Success 200 {object} models.PostDetailResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /amg/v1/posts/preview/{id} [get]
+func (h *PostHandler) GetPostPreview(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid post ID"})
+	}
+	token := c.Query("token")
+	if token == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "token is required"})
+	}
+
+	var post models.Post
+	collection := h.DB.Database(config.DBName).Collection("Post")
+	if err := collection.FindOne(context.TODO(), bson.M{"_id": id}).Decode(&post); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Post not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "DB error"})
+	}
+
+	if err := service.VerifyPreviewToken(token, post.ID, post.PreviewVersion); err != nil {
+		if errors.Is(err, service.ErrExpiredPreviewToken) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Preview link has expired"})
+		}
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Invalid preview link"})
+	}
+
+	detail := h.postDetail(post)
+	// Drafts' attachments are only sent with the preview token
+	for i := range detail.Attachments {
+		detail.Attachments[i].URL += "?token=" + url.QueryEscape(token)
+	}
+
+	c.Set("X-Robots-Tag", "noindex, nofollow")
+	c.Set(fiber.HeaderCacheControl, "private, no-store")
+	return c.JSON(detail)
+}
@@ -1,10 +1,16 @@
 package post
 
 import (
+	"amg-backend/middleware"
+	"amg-backend/service"
 	"github.com/gofiber/fiber/v2"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
+// MaxRequestBodySize is how large a create-post or update-post request may be:
+// every inline image at its largest, base64 encoded, with room for the rest.
+const MaxRequestBodySize = service.MaxInlineImagesPerPost*service.MaxInlineImageSize*4/3 + fiber.DefaultBodyLimit
+
 type PostHandler struct {
 	Router fiber.Router
 	DB     *mongo.Client
@@ -22,8 +28,21 @@ func RegisterPostHandler(router fiber.Router, db *mongo.Client) {
 	router.Get("/get-posts-by-category/:category", postHandler.GetPostsByCategory)
 	router.Get("/get-single-post-by-category/:category", postHandler.GetSinglePostByCategory)
 	router.Get("/get-posts-by-status/:status", postHandler.GetPostsByStatus)
-	router.Post("/update-post/:id", postHandler.UpdatePost)
-	router.Post("/create-post", postHandler.CreatePost)
-	router.Post("/delete-post/:id", postHandler.DeletePost)
-	router.Post("/recovery-post/:id", postHandler.RecoveryPost)
+	router.Get("/get-popular-posts", postHandler.GetPopularPosts)
+	router.Get("/get-featured-posts", postHandler.GetFeaturedPosts)
+	router.Get("/get-related-posts/:id", postHandler.GetRelatedPosts)
+	router.Get("/preview/:id", postHandler.GetPostPreview)
+	router.Post("/update-post/:id", middleware.RequireStaff, postHandler.UpdatePost)
+	router.Post("/create-post", middleware.RequireStaff, postHandler.CreatePost)
+	router.Post("/delete-post/:id", middleware.RequireStaff, postHandler.DeletePost)
+	router.Post("/recovery-post/:id", middleware.RequireStaff, postHandler.RecoveryPost)
+	router.Post("/pin-post/:id", middleware.RequireStaff, postHandler.PinPost)
+	router.Post("/unpin-post/:id", middleware.RequireStaff, postHandler.UnpinPost)
+	router.Post("/reorder-pinned-posts/:category", middleware.RequireStaff, postHandler.ReorderPinnedPosts)
+	router.Post("/set-featured/:id", middleware.RequireStaff, postHandler.SetFeaturedPost)
+	router.Post("/regenerate-summaries", middleware.RequireStaff, postHandler.RegeneratePostSummaries)
+	router.Post("/bulk-action", middleware.RequireStaff, postHandler.BulkPostAction)
+	router.Post("/create-preview-link/:id", middleware.RequireStaff, postHandler.CreatePreviewLink)
+	router.Post("/revoke-preview-links/:id", middleware.RequireStaff, postHandler.RevokePreviewLinks)
+	router.Post("/import-wordpress", middleware.RequireStaff, postHandler.ImportWordPress)
 }
@@ -0,0 +1,29 @@
+package tour
+
+import (
+	"amg-backend/middleware"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type TourHandler struct {
+	Router fiber.Router
+	DB     *mongo.Client
+}
+
+func RegisterTourHandler(router fiber.Router, db *mongo.Client) {
+	tourHandler := TourHandler{
+		Router: router,
+		DB:     db,
+	}
+
+	// Register all endpoints here
+	router.Get("/get-available-slots", tourHandler.GetAvailableSlots)
+	router.Get("/get-rules", middleware.RequireStaff, tourHandler.GetRules)
+	router.Post("/create-rule", middleware.RequireStaff, tourHandler.CreateRule)
+	router.Post("/update-rule/:id", middleware.RequireStaff, tourHandler.UpdateRule)
+	router.Get("/get-bookings", middleware.RequireStaff, tourHandler.GetBookings)
+	router.Post("/book", middleware.RequireStaff, tourHandler.BookTour)
+	router.Post("/reschedule-booking/:id", middleware.RequireStaff, tourHandler.RescheduleBooking)
+	router.Post("/cancel-booking/:id", middleware.RequireStaff, tourHandler.CancelBooking)
+}
@@ -0,0 +1,277 @@
+package tour
+
+import (
+	"amg-backend/middleware"
+	"amg-backend/models"
+	"amg-backend/service"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"strings"
+	"time"
+)
+
+// GetAvailableSlots godoc
+// @Summary List tour slots with free places
+// @Description Lists the upcoming campus tour slots that still have free places, soonest first. The public candidate form offers these; pass the chosen slot's id as tour_slot_id to create-candidate.
+// @Tags tour
+// @Accept json
+// @Produce json
+// @Param campus query string false "Only slots of this campus"
+// @Param days query int false "How many days ahead (default 14, max 60)"
+// @Success 200 {array} models.TourSlot
+// @Failure 500 {object} map[string]string
+// @Router /amg/v1/tours/get-available-slots [get]
+func (h *TourHandler) GetAvailableSlots(c *fiber.Ctx) error {
+	days := c.QueryInt("days", 14)
+	if days < 1 || days > service.MaxTourDays {
+		days = 14
+	}
+
+	slots, err := service.AvailableTourSlots(h.DB, strings.TrimSpace(c.Query("campus")), days)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "DB error"})
+	}
+	return c.JSON(slots)
+}
+
+// GetRules godoc
+// @Summary List recurring tour slots
+// @Description Lists the weekly tour slots of every campus, or of one. Staff only.
+// @Tags tour
+// @Accept json
+// @Produce json
+// @Param campus query string false "Only rules of this campus"
+// @Success 200 {array} models.TourSlotRule
+// @Failure 500 {object} map[string]string
+// @Router /amg/v1/tours/get-rules [get]
+func (h *TourHandler) GetRules(c *fiber.Ctx) error {
+	rules, err := service.ListTourRules(h.DB, strings.TrimSpace(c.Query("campus")))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "DB error"})
+	}
+	return c.JSON(rules)
+}
+
+// CreateRule godoc
+// @Summary Create a recurring tour slot
+// @Description Defines a weekly tour at a campus: weekday (0 = Sunday), start time (HH:MM, in TOUR.TIMEZONE), duration in minutes (default 60) and how many families can join. Staff only.
+// @Tags tour
+// @Accept json
+// @Produce json
+// @Param body body models.TourSlotRulePayload true "Rule"
+// @Success 201 {object} models.TourSlotRule
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /amg/v1/tours/create-rule [post]
+func (h *TourHandler) CreateRule(c *fiber.Ctx) error {
+	var payload models.TourSlotRulePayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+	rule, err := service.ValidateTourRule(payload)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "campus, weekday (0-6), start_time (HH:MM) and a capacity of at least 1 are required"})
+	}
+
+	rule, err = service.CreateTourRule(h.DB, rule)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create rule"})
+	}
+	return c.Status(fiber.StatusCreated).JSON(rule)
+}
+
+// UpdateRule godoc
+// @Summary Update a recurring tour slot
+// @Description Replaces a weekly tour slot, or deactivates it with active=false. Upcoming tours that already have bookings keep their time and take the new capacity. Staff only.
+// @Tags tour
+// @Accept json
+// @Produce json
+// @Param id path string true "Rule ID"
+// @Param body body models.TourSlotRulePayload true "Rule"
+// @Success 200 {object} models.TourSlotRule
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /amg/v1/tours/update-rule/{id} [post]
+func (h *TourHandler) UpdateRule(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+	var payload models.TourSlotRulePayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+	rule, err := service.ValidateTourRule(payload)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "campus, weekday (0-6), start_time (HH:MM) and a capacity of at least 1 are required"})
+	}
+
+	rule, err = service.UpdateTourRule(h.DB, id, rule)
+	if errors.Is(err, service.ErrTourRuleNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Rule not found"})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update rule"})
+	}
+	return c.JSON(rule)
+}
+
+// GetBookings godoc
+// @Summary List tour bookings
+// @Description Lists the bookings of tours in a period, soonest first. Staff only.
+// @Tags tour
+// @Accept json
+// @Produce json
+// @Param campus query string false "Only bookings of this campus"
+// @Param from query string false "First day (YYYY-MM-DD, default today)"
+// @Param to query string false "Last day (YYYY-MM-DD, default 14 days after from)"
+// @Param include_cancelled query bool false "Include cancelled bookings"
+// @Success 200 {array} models.TourBooking
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /amg/v1/tours/get-bookings [get]
+func (h *TourHandler) GetBookings(c *fiber.Ctx) error {
+	loc := service.TourLocation()
+	now := time.Now().In(loc)
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	if value := c.Query("from"); value != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", value, loc)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "from must be YYYY-MM-DD"})
+		}
+		from = parsed
+	}
+	to := from.AddDate(0, 0, 14)
+	if value := c.Query("to"); value != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", value, loc)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "to must be YYYY-MM-DD"})
+		}
+		to = parsed
+	}
+	to = to.AddDate(0, 0, 1).Add(-time.Nanosecond)
+
+	bookings, err := service.ListTourBookings(h.DB, strings.TrimSpace(c.Query("campus")), from, to, c.QueryBool("include_cancelled"))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "DB error"})
+	}
+	return c.JSON(bookings)
+}
+
+// BookTour godoc
+// @Summary Book a tour for a candidate
+// @Description Reserves a place on a tour slot for a candidate without a booking, e.g. after a phone call. The candidate moves to tour_scheduled where the admissions funnel allows it, and the booking is added to their timeline. Staff only.
+// @Tags tour
+// @Accept json
+// @Produce json
+// @Param body body models.BookTourPayload true "Candidate and slot"
+// @Success 201 {object} models.TourBooking
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /amg/v1/tours/book [post]
+func (h *TourHandler) BookTour(c *fiber.Ctx) error {
+	var payload models.BookTourPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+	candidateID, err := primitive.ObjectIDFromHex(payload.CandidateID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid candidate_id"})
+	}
+	slotID, err := primitive.ObjectIDFromHex(payload.SlotID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid slot_id"})
+	}
+
+	user, _ := middleware.CurrentUser(c)
+	booking, err := service.BookTour(h.DB, candidateID, slotID, user.ID, user.Username)
+	if err != nil {
+		return tourError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(booking)
+}
+
+// RescheduleBooking godoc
+// @Summary Move a tour booking to another slot
+// @Description Moves a booking to another slot with a free place; if that slot is full the booking stays as it was. The change is added to the candidate's timeline. Staff only.
+// @Tags tour
+// @Accept json
+// @Produce json
+// @Param id path string true "Booking ID"
+// @Param body body models.RescheduleTourPayload true "New slot"
+// @Success 200 {object} models.TourBooking
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /amg/v1/tours/reschedule-booking/{id} [post]
+func (h *TourHandler) RescheduleBooking(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+	var payload models.RescheduleTourPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+	slotID, err := primitive.ObjectIDFromHex(payload.SlotID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid slot_id"})
+	}
+
+	user, _ := middleware.CurrentUser(c)
+	booking, err := service.RescheduleTour(h.DB, id, slotID, user.ID, user.Username)
+	if err != nil {
+		return tourError(c, err)
+	}
+	return c.JSON(booking)
+}
+
+// CancelBooking godoc
+// @Summary Cancel a tour booking
+// @Description Cancels a booking and frees its place. The cancellation and its reason are added to the candidate's timeline. Staff only.
+// @Tags tour
+// @Accept json
+// @Produce json
+// @Param id path string true "Booking ID"
+// @Param body body models.CancelTourPayload false "Reason"
+// @Success 200 {object} models.TourBooking
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /amg/v1/tours/cancel-booking/{id} [post]
+func (h *TourHandler) CancelBooking(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+	var payload models.CancelTourPayload
+	_ = c.BodyParser(&payload)
+
+	user, _ := middleware.CurrentUser(c)
+	booking, err := service.CancelTour(h.DB, id, strings.TrimSpace(payload.Reason), user.ID, user.Username)
+	if err != nil {
+		return tourError(c, err)
+	}
+	return c.JSON(booking)
+}
+
+func tourError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, service.ErrCandidateNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Candidate not found"})
+	case errors.Is(err, service.ErrTourSlotNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Tour slot not found or already past"})
+	case errors.Is(err, service.ErrTourBookingMissing):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Booking not found or cancelled"})
+	case errors.Is(err, service.ErrTourSlotFull):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "This tour is fully booked"})
+	case errors.Is(err, service.ErrTourAlreadyBooked):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "The candidate already has a tour booked; reschedule it instead"})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "DB error"})
+}
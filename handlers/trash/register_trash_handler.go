@@ -0,0 +1,23 @@
+package trash
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type TrashHandler struct {
+	Router fiber.Router
+	DB     *mongo.Client
+}
+
+func RegisterTrashHandler(router fiber.Router, db *mongo.Client) {
+	trashHandler := TrashHandler{
+		Router: router,
+		DB:     db,
+	}
+
+	// Register all endpoints here
+	router.Get("/get-trash", trashHandler.GetTrash)
+	router.Post("/restore/:type/:id", trashHandler.RestoreTrashItem)
+	router.Post("/purge/:type/:id", trashHandler.PurgeTrashItem)
+}
@@ -0,0 +1,97 @@
+package trash
+
+import (
+	"amg-backend/service"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GetTrash godoc
+// @Summary List the trash
+// @Description Lists soft-deleted posts, candidates and comments, most recently deleted first, with the date each one will be purged
+// @Tags trash
+// @Accept json
+// @Produce json
+// @Param type query string false "Only list one type: post, candidate or comment"
+// @Success 200 {array} models.TrashItem
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /amg/v1/trash/get-trash [get]
+func (h *TrashHandler) GetTrash(c *fiber.Ctx) error {
+	items, err := service.ListTrash(h.DB, c.Query("type"))
+	if err != nil {
+		if errors.Is(err, service.ErrUnknownTrashType) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid type"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "DB error"})
+	}
+
+	return c.JSON(items)
+}
+
+// RestoreTrashItem godoc
+// @Summary Restore an item from the trash
+// @Description Restores a soft-deleted item to the status it had before deletion
+// @Tags trash
+// @Accept json
+// @Produce json
+// @Param type path string true "Item type: post, candidate or comment"
+// @Param id path string true "Item ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /amg/v1/trash/restore/{type}/{id} [post]
+func (h *TrashHandler) RestoreTrashItem(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+
+	found, err := service.RestoreFromTrash(h.DB, c.Params("type"), id)
+	if err != nil {
+		if errors.Is(err, service.ErrUnknownTrashType) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid type"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Restore failed"})
+	}
+	if !found {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Item not found in trash"})
+	}
+
+	return c.JSON(fiber.Map{"message": "Item restored successfully"})
+}
+
+// PurgeTrashItem godoc
+// @Summary Permanently delete an item from the trash
+// @Description Permanently deletes a soft-deleted item without waiting for the retention period. Images and attachments of a post are released to the cleanup jobs.
+// @Tags trash
+// @Accept json
+// @Produce json
+// @Param type path string true "Item type: post, candidate or comment"
+// @Param id path string true "Item ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /amg/v1/trash/purge/{type}/{id} [post]
+func (h *TrashHandler) PurgeTrashItem(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+
+	found, err := service.PurgeTrashItem(h.DB, c.Params("type"), id)
+	if err != nil {
+		if errors.Is(err, service.ErrUnknownTrashType) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid type"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Purge failed"})
+	}
+	if !found {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Item not found in trash"})
+	}
+
+	return c.JSON(fiber.Map{"message": "Item permanently deleted"})
+}
@@ -1,6 +1,7 @@
 package user
 
 import (
+	"amg-backend/middleware"
 	"github.com/gofiber/fiber/v2"
 	"go.mongodb.org/mongo-driver/mongo"
 )
@@ -22,4 +23,5 @@ func RegisterUserHandler(router fiber.Router, db *mongo.Client) {
 	router.Post("/update-user/:id", userHandler.UpdateUser)
 	router.Post("/deactivate-user/:id", userHandler.DeactivateUser)
 	router.Post("/reactivate-user/:id", userHandler.ReactivateUser)
+	router.Post("/set-classes/:id", middleware.RequireStaff, userHandler.SetUserClasses)
 }
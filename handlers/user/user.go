@@ -2,6 +2,7 @@ package user
 
 import (
 	"amg-backend/config"
+	"amg-backend/middleware"
 	"amg-backend/models"
 	"context"
 	"errors"
@@ -9,6 +10,8 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"strings"
 	"time"
 )
 
@@ -72,7 +75,7 @@ func (h *UserHandler) GetUsersById(c *fiber.Ctx) error {
 
 // UpdateUser godoc
 // @Summary Update user information
-// @Description Updates user information based on the provided ID
+// @Description Updates user information based on the provided ID. Users can update their own account and staff any account. The role and classes are not changed.
 // @Tags user
 // @Accept json
 // @Produce json
@@ -80,22 +83,31 @@ func (h *UserHandler) GetUsersById(c *fiber.Ctx) error {
 // @Param body body models.User true "User data to update"
 // @Success 200 {object} map[string]string
 // @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /amg/v1/users/update-user/{id} [post]
 func (h *UserHandler) UpdateUser(c *fiber.Ctx) error {
 	idParam := c.Params("id")
 	id, _ := primitive.ObjectIDFromHex(idParam)
-	var user models.User
-	if err := c.BodyParser(&user); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid input"})
+	session, ok := middleware.CurrentUser(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "not authenticated"})
+	}
+	if session.ID != idParam && !session.IsStaff() {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "you can only update your own account"})
 	}
 
 	updateData := bson.M{}
 	if err := c.BodyParser(&updateData); err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": "invalid input"})
 	}
+	// The role and classes decide what a user can see and do, so they are
+	// not changed here; classes are set with set-classes
+	delete(updateData, "role")
+	delete(updateData, "classes")
+	delete(updateData, "_id")
 	updateData["update_at"] = time.Now()
-	updateData["role"] = user.Role
 
 	collection := h.DB.Database(config.DBName).Collection("User")
 	_, err := collection.UpdateByID(context.TODO(), id, bson.M{"$set": updateData})
@@ -156,3 +168,55 @@ func (h *UserHandler) ReactivateUser(c *fiber.Ctx) error {
 	}
 	return c.JSON(fiber.Map{"message": "reactivated"})
 }
+
+// SetUserClasses godoc
+// @Summary Set the classes of a parent's children
+// @Description Replaces the classes a parent's children are in. Posts with visibility "classes" are shown to parents with one of the post's classes. An empty list removes them all. Staff only.
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param body body models.SetUserClassesPayload true "Classes"
+// @Success 200 {object} models.User
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /amg/v1/users/set-classes/{id} [post]
+func (h *UserHandler) SetUserClasses(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+	var payload models.SetUserClassesPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+
+	classes := make([]string, 0, len(payload.Classes))
+	seen := make(map[string]bool)
+	for _, class := range payload.Classes {
+		class = strings.TrimSpace(class)
+		if class != "" && !seen[class] {
+			seen[class] = true
+			classes = append(classes, class)
+		}
+	}
+	update := bson.M{"$set": bson.M{"classes": classes, "update_at": time.Now()}}
+	if len(classes) == 0 {
+		update = bson.M{"$set": bson.M{"update_at": time.Now()}, "$unset": bson.M{"classes": ""}}
+	}
+
+	var user models.User
+	err = h.DB.Database(config.DBName).Collection("User").FindOneAndUpdate(context.TODO(),
+		bson.M{"_id": id},
+		update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&user)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "update failed"})
+	}
+	return c.JSON(user)
+}
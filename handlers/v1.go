@@ -2,13 +2,19 @@ package feature
 
 import (
 	"amg-backend/cronjobs"
+	"amg-backend/handlers/attachment"
 	"amg-backend/handlers/auth"
 	"amg-backend/handlers/candidate"
+	"amg-backend/handlers/captcha"
 	"amg-backend/handlers/comment"
+	"amg-backend/handlers/integrity"
 	"amg-backend/handlers/landing_page"
 	"amg-backend/handlers/post"
+	"amg-backend/handlers/tour"
+	"amg-backend/handlers/trash"
 	"amg-backend/handlers/uploaded_image"
 	"amg-backend/handlers/user"
+	"amg-backend/middleware"
 	"github.com/go-co-op/gocron"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -25,23 +31,81 @@ func RegisterHandlerV1(db *mongo.Client) *fiber.App {
 
 	_, err := s.Every(1).Day().At("02:00").Do(func() {
 		cronjobs.RunImageCleanupJob(db)
+		cronjobs.RunAttachmentCleanupJob(db)
 	})
 	if err != nil {
 		log.Fatalf("Could not schedule cron job: %v", err)
 	}
 
+	_, err = s.Every(1).Day().At("01:30").Do(func() {
+		cronjobs.RunTrashPurgeJob(db)
+	})
+	if err != nil {
+		log.Fatalf("Could not schedule cron job: %v", err)
+	}
+
+	_, err = s.Every(1).Day().At("03:00").Do(func() {
+		cronjobs.RunRelatedPostsRefreshJob(db)
+	})
+	if err != nil {
+		log.Fatalf("Could not schedule cron job: %v", err)
+	}
+
+	_, err = s.Every(1).Day().At("03:30").Do(func() {
+		cronjobs.RunCommentStatsRefreshJob(db)
+	})
+	if err != nil {
+		log.Fatalf("Could not schedule cron job: %v", err)
+	}
+
+	_, err = s.Every(1).Day().At("00:30").Do(func() {
+		cronjobs.RunTourSlotGenerationJob(db)
+	})
+	if err != nil {
+		log.Fatalf("Could not schedule cron job: %v", err)
+	}
+
+	_, err = s.Every(1).Day().At("00:45").Do(func() {
+		cronjobs.RunCandidateRefreshJob(db)
+	})
+	if err != nil {
+		log.Fatalf("Could not schedule cron job: %v", err)
+	}
+
+	_, err = s.Every(1).Day().At("04:00").Do(func() {
+		cronjobs.RunIntegrityCheckJob(db)
+	})
+	if err != nil {
+		log.Fatalf("Could not schedule cron job: %v", err)
+	}
+
+	// Slots are only generated by this job and by rule changes, so the
+	// booking window is brought up to date at startup as well
+	go cronjobs.RunTourSlotGenerationJob(db)
+	// Candidates saved by older versions are brought up to date at once
+	go cronjobs.RunCandidateRefreshJob(db)
+
 	s.StartAsync()
 	log.Println("Cron job scheduler started.")
-	router := fiber.New()
+	router := fiber.New(fiber.Config{
+		// Bodies are limited per route by middleware.LimitBody, so they are
+		// streamed rather than read and refused by one app-wide limit
+		StreamRequestBody:            true,
+		DisablePreParseMultipartForm: true,
+	})
 	router.Static("/uploads", "./uploads")
 	router.Use(cors.New(cors.Config{
 		AllowOrigins: "*",
 		//AllowCredentials: true,
 		AllowMethods: "GET,POST,PUT,DELETE,OPTIONS",
-		AllowHeaders: "Origin, Content-Type, Accept, Authorization",
+		AllowHeaders: "Origin, Content-Type, Accept, Authorization, X-Captcha-Token, X-Captcha-Solution",
 	}))
 
 	v1 := router.Group("/amg/v1")
+	v1.Use("/posts/create-post", middleware.RaiseBodyLimit(post.MaxRequestBodySize))
+	v1.Use("/posts/update-post", middleware.RaiseBodyLimit(post.MaxRequestBodySize))
+	v1.Use("/attachments/upload-attachment", middleware.RaiseBodyLimit(attachment.MaxRequestBodySize))
+	v1.Use(middleware.LimitBody)
 	v1.Get("/swagger/*", swagger.HandlerDefault)
 	auth.RegisterAuthHandler(v1.Group("/auth-self"), db)
 	user.RegisterUserHandler(v1.Group("/users"), db)
@@ -50,5 +114,10 @@ func RegisterHandlerV1(db *mongo.Client) *fiber.App {
 	uploaded_image.RegisterUploadedImageHandler(v1.Group("/images"), db)
 	landing_page.RegisterLandingPageHandler(v1.Group("/landing-page"), db)
 	comment.RegisterCommentHandler(v1.Group("/comments"), db)
+	attachment.RegisterAttachmentHandler(v1.Group("/attachments"), db)
+	trash.RegisterTrashHandler(v1.Group("/trash", middleware.RequireStaff), db)
+	integrity.RegisterIntegrityHandler(v1.Group("/integrity", middleware.RequireStaff), db)
+	captcha.RegisterCaptchaHandler(v1.Group("/captcha"), db)
+	tour.RegisterTourHandler(v1.Group("/tours"), db)
 	return router
 }
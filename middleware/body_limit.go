@@ -0,0 +1,37 @@
+package middleware
+
+import "github.com/gofiber/fiber/v2"
+
+// bodyLimitKey holds the body limit raised for the current route.
+const bodyLimitKey = "bodyLimit"
+
+// RaiseBodyLimit lets the routes it is mounted on accept bodies of up to limit
+// bytes rather than fiber.DefaultBodyLimit. Mount it before LimitBody.
+func RaiseBodyLimit(limit int) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Locals(bodyLimitKey, limit)
+		return c.Next()
+	}
+}
+
+// LimitBody rejects bodies over the route's limit before they are read. The
+// app streams request bodies instead of reading them up front, so bodies are
+// limited here, by their Content-Length; chunked bodies of unknown length are
+// refused.
+func LimitBody(c *fiber.Ctx) error {
+	limit, ok := c.Locals(bodyLimitKey).(int)
+	if !ok {
+		limit = fiber.DefaultBodyLimit
+	}
+	// -1 is a chunked body; other negative lengths mean there is no body
+	length := c.Request().Header.ContentLength()
+	if length != -1 && length <= limit {
+		return c.Next()
+	}
+	// The unread body is still on the connection, so it cannot be reused
+	c.Context().SetConnectionClose()
+	if length < 0 {
+		return c.Status(fiber.StatusLengthRequired).JSON(fiber.Map{"error": "Content-Length is required"})
+	}
+	return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{"error": "request body too large"})
+}
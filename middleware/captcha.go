@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"amg-backend/service"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// RequireCaptcha rejects anonymous submissions that do not carry a solved,
+// unused challenge from /captcha/get-challenge in the X-Captcha-Token and
+// X-Captcha-Solution headers. Logged-in users skip the check.
+func RequireCaptcha(db *mongo.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !service.CaptchaEnabled() {
+			return c.Next()
+		}
+		if _, ok := CurrentUser(c); ok {
+			return c.Next()
+		}
+
+		err := service.VerifyCaptcha(db, c.Get("X-Captcha-Token"), c.Get("X-Captcha-Solution"))
+		switch {
+		case err == nil:
+			return c.Next()
+		case errors.Is(err, service.ErrCaptchaRequired):
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "captcha required"})
+		case errors.Is(err, service.ErrExpiredCaptcha):
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "captcha expired"})
+		case errors.Is(err, service.ErrUsedCaptcha):
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "captcha already used"})
+		case errors.Is(err, service.ErrInvalidCaptcha):
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "invalid captcha"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "DB error"})
+	}
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"amg-backend/config"
+	"amg-backend/models"
+	"amg-backend/service"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/mongo"
+	"strings"
+)
+
+// SessionUser is the identity carried by the session token issued at login.
+type SessionUser struct {
+	ID       string
+	Username string
+	Role     string
+}
+
+// IsStaff reports whether the user works for the school, as opposed to a parent.
+func (u *SessionUser) IsStaff() bool {
+	return u != nil && models.IsStaffRole(u.Role)
+}
+
+// CurrentUser returns the user of the session token sent in the
+// session_token cookie or, failing that, as a Bearer Authorization header.
+func CurrentUser(c *fiber.Ctx) (*SessionUser, bool) {
+	tokenString := c.Cookies("session_token")
+	if tokenString == "" {
+		tokenString = strings.TrimPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+	}
+	if tokenString == "" {
+		return nil, false
+	}
+
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fiber.NewError(fiber.StatusUnauthorized, "unexpected signing method")
+		}
+		return []byte(config.SecretKey), nil
+	})
+	if err != nil || !token.Valid {
+		return nil, false
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, false
+	}
+
+	user := &SessionUser{}
+	user.ID, _ = claims["id"].(string)
+	user.Username, _ = claims["username"].(string)
+	user.Role, _ = claims["role"].(string)
+	if user.ID == "" {
+		return nil, false
+	}
+	return user, true
+}
+
+// RequireStaff rejects requests that do not come from a logged-in staff member.
+func RequireStaff(c *fiber.Ctx) error {
+	user, ok := CurrentUser(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "not authenticated"})
+	}
+	if !user.IsStaff() {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "staff only"})
+	}
+	return c.Next()
+}
+
+// PostViewer returns who is making the request, for post visibility checks.
+// Anonymous requests get the zero viewer, which only sees public posts.
+func PostViewer(c *fiber.Ctx, db *mongo.Client) service.PostViewer {
+	user, ok := CurrentUser(c)
+	if !ok {
+		return service.PostViewer{}
+	}
+	return service.LoadPostViewer(db, user.ID, user.Role)
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type AttachmentStatus string
+
+const (
+	AttachmentStatusPending AttachmentStatus = "pending"
+	AttachmentStatusUsed    AttachmentStatus = "used"
+)
+
+// Attachment is a document (PDF, Word, Excel, ...) published with a post.
+// Like UploadedImage it stays 'pending' until a post references it, and
+// pending attachments are removed by the cleanup job.
+type Attachment struct {
+	ID           primitive.ObjectID  `bson:"_id,omitempty" json:"id,omitempty"`
+	PostID       *primitive.ObjectID `bson:"post_id,omitempty" json:"post_id,omitempty"`
+	OriginalName string              `bson:"original_name" json:"original_name"`
+	Filename     string              `bson:"filename" json:"filename"`
+	Path         string              `bson:"path" json:"-"`
+	URL          string              `bson:"url" json:"url"`
+	MimeType     string              `bson:"mime_type" json:"mime_type"`
+	Size         int64               `bson:"size" json:"size"`
+	Status       AttachmentStatus    `bson:"status" json:"status"`
+	CreatedAt    time.Time           `bson:"createdAt" json:"createdAt"`
+}
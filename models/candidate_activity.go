@@ -0,0 +1,60 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+const (
+	CandidateActivityNote         = "note"
+	CandidateActivityCall         = "call"
+	CandidateActivityEmail        = "email"
+	CandidateActivityStatusChange = "status_change"
+	CandidateActivityTour         = "tour"
+	CandidateActivityMerge        = "merge"
+)
+
+// IsManualCandidateActivity reports whether staff can add activities of the
+// type themselves; status changes, tour bookings and merges are recorded by
+// the endpoints that make them.
+func IsManualCandidateActivity(kind string) bool {
+	return kind == CandidateActivityNote || kind == CandidateActivityCall || kind == CandidateActivityEmail
+}
+
+// CandidateActivity is an entry in a candidate's timeline.
+type CandidateActivity struct {
+	ID          primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	CandidateID primitive.ObjectID `json:"candidate_id" bson:"candidate_id"`
+	Type        string             `json:"type" bson:"type"`
+	// Channel is how the family was reached, e.g. phone, zalo, email, in person
+	Channel    string    `json:"channel,omitempty" bson:"channel,omitempty"`
+	Content    string    `json:"content,omitempty" bson:"content,omitempty"`
+	AuthorID   string    `json:"author_id" bson:"author_id"`
+	AuthorName string    `json:"author_name" bson:"author_name"`
+	CreatedAt  time.Time `json:"created_at" bson:"created_at"`
+
+	// When staff should get back to the family
+	NextFollowUp *time.Time `json:"next_follow_up,omitempty" bson:"next_follow_up,omitempty"`
+
+	// Set for status changes
+	FromStatus string `json:"from_status,omitempty" bson:"from_status,omitempty"`
+	ToStatus   string `json:"to_status,omitempty" bson:"to_status,omitempty"`
+}
+
+// CandidateActivitySummary is the latest activity, kept on the candidate for listings.
+type CandidateActivitySummary struct {
+	Type         string     `json:"type" bson:"type"`
+	Channel      string     `json:"channel,omitempty" bson:"channel,omitempty"`
+	AuthorName   string     `json:"author_name" bson:"author_name"`
+	At           time.Time  `json:"at" bson:"at"`
+	NextFollowUp *time.Time `json:"next_follow_up,omitempty" bson:"next_follow_up,omitempty"`
+}
+
+// AddCandidateActivityPayload adds a note, call or email to a timeline.
+// NextFollowUp is a date (2006-01-02) or an RFC 3339 time.
+type AddCandidateActivityPayload struct {
+	Type         string `json:"type"`
+	Channel      string `json:"channel"`
+	Content      string `json:"content"`
+	NextFollowUp string `json:"next_follow_up"`
+}
@@ -5,6 +5,36 @@ import (
 	"time"
 )
 
+// Stages of the admissions funnel. A candidate moves forward from new to
+// enrolled, or leaves it as declined (the family turned down an offer or
+// application) or lost (the family stopped responding or chose elsewhere).
+const (
+	CandidateStatusNew           = "new"
+	CandidateStatusContacted     = "contacted"
+	CandidateStatusTourScheduled = "tour_scheduled"
+	CandidateStatusApplied       = "applied"
+	CandidateStatusOffered       = "offered"
+	CandidateStatusEnrolled      = "enrolled"
+	CandidateStatusDeclined      = "declined"
+	CandidateStatusLost          = "lost"
+
+	// CandidateStatusSpam is given to submissions the content filters flagged.
+	// They stay out of the candidate list until staff change their status.
+	CandidateStatusSpam = "spam"
+)
+
+// Kindergarten groups, by the age the child turns in the year the school year
+// starts: nhà trẻ (1-2), mầm (3), chồi (4) and lá (5). Older children are
+// school age and younger ones are too young to join yet.
+const (
+	AgeGroupTooYoung  = "too_young"
+	AgeGroupNursery   = "nha_tre"
+	AgeGroupMam       = "mam"
+	AgeGroupChoi      = "choi"
+	AgeGroupLa        = "la"
+	AgeGroupSchoolAge = "school_age"
+)
+
 type Candidate struct {
 	ID          primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
 	StudentName string             `json:"student_name" bson:"student_name"`
@@ -16,4 +46,63 @@ type Candidate struct {
 	Status      string             `json:"status" bson:"status"`
 	CreateAt    time.Time          `json:"create_at" bson:"create_at"`
 	UpdateAt    time.Time          `json:"update_at" bson:"update_at"`
+
+	// DateOfBirth parsed, with DateOfBirth kept as YYYY-MM-DD and Phone in E.164
+	BirthDate *time.Time `json:"birth_date,omitempty" bson:"birth_date,omitempty"`
+	// The child's kindergarten group, worked out when the candidate is saved
+	// and again by the daily candidate job as school years change
+	AgeGroup string `json:"age_group,omitempty" bson:"age_group,omitempty"`
+
+	// When the candidate entered each stage, keyed by status
+	StageDates map[string]time.Time `json:"stage_dates,omitempty" bson:"stage_dates,omitempty"`
+	// Why the candidate was declined or lost
+	ClosedReason string `json:"closed_reason,omitempty" bson:"closed_reason,omitempty"`
+
+	// The upcoming campus tour, if one is booked
+	Tour *CandidateTour `json:"tour,omitempty" bson:"tour,omitempty"`
+
+	// The newest entry of the candidate's timeline
+	LastActivity *CandidateActivitySummary `json:"last_activity,omitempty" bson:"last_activity,omitempty"`
+
+	// Normalised contact details used to spot families who sent the form twice
+	MatchKeys *CandidateMatchKeys `json:"-" bson:"match_keys,omitempty"`
+	// Candidates this one likely duplicates, until staff merge or dismiss them
+	PossibleDuplicates []primitive.ObjectID `json:"possible_duplicates,omitempty" bson:"possible_duplicates,omitempty"`
+	// Set on a duplicate merged into another candidate; it is kept in the trash
+	MergedInto *primitive.ObjectID `json:"merged_into,omitempty" bson:"merged_into,omitempty"`
+
+	// What the content filters found when the form was submitted
+	SpamScore   float64  `json:"spam_score,omitempty" bson:"spam_score,omitempty"`
+	SpamReasons []string `json:"spam_reasons,omitempty" bson:"spam_reasons,omitempty"`
+
+	// Set while in the trash; PreviousStatus is restored on recovery
+	DeletedAt      *time.Time `json:"deleted_at,omitempty" bson:"deleted_at,omitempty"`
+	PreviousStatus string     `json:"-" bson:"previous_status,omitempty"`
+}
+
+// CandidateTransitionPayload moves a candidate to another stage. Reason is
+// required when closing as declined or lost.
+type CandidateTransitionPayload struct {
+	Status string `json:"status"`
+	Reason string `json:"reason"`
+}
+
+// CandidateMatchKeys holds the normalised phone, parent name and child's date
+// of birth that duplicate detection compares.
+type CandidateMatchKeys struct {
+	Phone      string `bson:"phone,omitempty"`
+	ParentName string `bson:"parent_name,omitempty"`
+	DOB        string `bson:"dob,omitempty"`
+}
+
+// CandidateDuplicates is a candidate flagged as a likely duplicate together
+// with the candidates it matched.
+type CandidateDuplicates struct {
+	Candidate Candidate   `json:"candidate"`
+	Matches   []Candidate `json:"matches"`
+}
+
+// MergeCandidatePayload names the duplicate to merge into a candidate.
+type MergeCandidatePayload struct {
+	DuplicateID string `json:"duplicate_id"`
 }
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	// CaptchaKindProofOfWork asks the client to find a nonce such that
+	// sha256(challenge + ":" + nonce) starts with Difficulty zero bits
+	CaptchaKindProofOfWork = "pow"
+	// CaptchaKindArithmetic asks the user to solve Question
+	CaptchaKindArithmetic = "arithmetic"
+)
+
+// CaptchaChallenge is handed to public forms. The token and the solution are
+// sent back in the X-Captcha-Token and X-Captcha-Solution headers.
+type CaptchaChallenge struct {
+	Kind       string    `json:"kind"`
+	Token      string    `json:"token"`
+	Challenge  string    `json:"challenge,omitempty"`
+	Difficulty int       `json:"difficulty,omitempty"`
+	Question   string    `json:"question,omitempty"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// CaptchaSolution remembers a solved challenge so it cannot be used twice.
+// Records expire through a TTL index once the challenge could no longer be used.
+type CaptchaSolution struct {
+	ID     string    `bson:"_id"`
+	UsedAt time.Time `bson:"used_at"`
+}
@@ -5,6 +5,39 @@ import (
 	"time"
 )
 
+const (
+	// CommentStatusNew is what comments were created with before moderation
+	// existed; they count as published.
+	CommentStatusNew      = "new"
+	CommentStatusPending  = "pending"
+	CommentStatusApproved = "approved"
+	CommentStatusRejected = "rejected"
+	CommentStatusSpam     = "spam"
+)
+
+const (
+	ReactionLike   = "like"
+	ReactionHeart  = "heart"
+	ReactionThanks = "thanks"
+)
+
+// IsValidReaction reports whether kind is one of the supported reactions.
+func IsValidReaction(kind string) bool {
+	return kind == ReactionLike || kind == ReactionHeart || kind == ReactionThanks
+}
+
+const (
+	// ModerationPre holds comments until staff approve them
+	ModerationPre = "pre"
+	// ModerationPost publishes comments at once; staff can reject them later
+	ModerationPost = "post"
+)
+
+// IsPublishedCommentStatus reports whether comments with the status are shown to readers.
+func IsPublishedCommentStatus(status string) bool {
+	return status == CommentStatusNew || status == CommentStatusApproved
+}
+
 type Comment struct {
 	ID         primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
 	PostId     string             `bson:"post_id" json:"postId"`
@@ -14,11 +47,118 @@ type Comment struct {
 	Status     string             `bson:"status" json:"status"`
 	CreatedAt  time.Time          `bson:"created_at" json:"createdAt"`
 	UpdatedAt  time.Time          `bson:"updated_at" json:"updatedAt"`
+
+	// Replies point at the comment they answer; Depth is 0 for top-level comments
+	ParentId   string `bson:"parent_id,omitempty" json:"parentId,omitempty"`
+	Depth      int    `bson:"depth" json:"depth"`
+	AuthorRole string `bson:"author_role,omitempty" json:"authorRole,omitempty"`
+
+	// Set when the author or staff changed the content; earlier versions are
+	// kept as CommentRevision
+	EditedAt *time.Time `bson:"edited_at,omitempty" json:"editedAt,omitempty"`
+
+	// Set when staff approve, reject or flag the comment as spam
+	ModeratedAt *time.Time `bson:"moderated_at,omitempty" json:"moderatedAt,omitempty"`
+	ModeratedBy string     `bson:"moderated_by,omitempty" json:"moderatedBy,omitempty"`
+
+	// What the content filters found when the comment was submitted
+	SpamScore   float64  `bson:"spam_score,omitempty" json:"spamScore,omitempty"`
+	SpamReasons []string `bson:"spam_reasons,omitempty" json:"spamReasons,omitempty"`
+
+	// Number of readers who reported the comment since staff last approved it,
+	// and how many of them were signed in. Only the latter can hide it.
+	ReportCount       int `bson:"report_count,omitempty" json:"reportCount,omitempty"`
+	MemberReportCount int `bson:"member_report_count,omitempty" json:"memberReportCount,omitempty"`
+
+	// Set while in the trash; PreviousStatus is restored on recovery
+	DeletedAt      *time.Time `bson:"deleted_at,omitempty" json:"deletedAt,omitempty"`
+	PreviousStatus string     `bson:"previous_status,omitempty" json:"-"`
+}
+
+// CommentView is a comment as returned to readers. Deleted comments that
+// still have replies are kept as empty placeholders so the thread stays whole.
+type CommentView struct {
+	Comment
+	IsStaff bool           `json:"isStaff"`
+	Edited  bool           `json:"edited"`
+	Deleted bool           `json:"deleted,omitempty"`
+	Replies []*CommentView `json:"replies,omitempty"`
+
+	// Reactions counts the reactions by type; MyReaction is the requester's own
+	Reactions  map[string]int `json:"reactions,omitempty"`
+	MyReaction string         `json:"myReaction,omitempty"`
 }
 
 type CreateCommentPayload struct {
 	PostId     string `json:"postId"`
-	AuthorId   string `json:"authorId,omitempty"`
+	ParentId   string `json:"parentId,omitempty"`
 	AuthorName string `json:"authorName"`
 	Content    string `json:"content"`
+
+	// Honeypot; hidden from people, so anything in it marks the comment as spam
+	Website string `json:"website,omitempty"`
+}
+
+// UpdateCommentPayload replaces the content of a comment.
+type UpdateCommentPayload struct {
+	Content string `json:"content"`
+}
+
+// CommentRevision is an earlier version of an edited comment.
+type CommentRevision struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
+	CommentID primitive.ObjectID `bson:"comment_id" json:"commentId"`
+	Content   string             `bson:"content" json:"content"`
+	// EditedBy is the user whose edit replaced this version
+	EditedBy  string    `bson:"edited_by,omitempty" json:"editedBy,omitempty"`
+	CreatedAt time.Time `bson:"created_at" json:"createdAt"`
+}
+
+// CommentReaction is one user's reaction to a comment; a user has at most one
+// per comment.
+type CommentReaction struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
+	CommentID primitive.ObjectID `bson:"comment_id" json:"commentId"`
+	UserID    string             `bson:"user_id" json:"userId"`
+	Type      string             `bson:"type" json:"type"`
+	CreatedAt time.Time          `bson:"created_at" json:"createdAt"`
+}
+
+type ReactCommentPayload struct {
+	// Type is one of like, heart, thanks
+	Type string `json:"type"`
+}
+
+// CommentReport is a reader flagging a comment as inappropriate. Reporter is
+// the user ID of logged-in readers and an anonymous visitor ID otherwise.
+type CommentReport struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
+	CommentID primitive.ObjectID `bson:"comment_id" json:"commentId"`
+	Reporter  string             `bson:"reporter" json:"reporter"`
+	Reason    string             `bson:"reason" json:"reason"`
+	CreatedAt time.Time          `bson:"created_at" json:"createdAt"`
+}
+
+type ReportCommentPayload struct {
+	Reason string `json:"reason"`
+}
+
+type ModerateCommentPayload struct {
+	// Action is one of approve, reject, spam
+	Action string `json:"action"`
+}
+
+// CommentModerationSetting overrides the moderation mode for one category.
+// An empty category overrides the COMMENT.MODERATION default for all of them.
+type CommentModerationSetting struct {
+	Category  string    `bson:"category" json:"category"`
+	Mode      string    `bson:"mode" json:"mode"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updatedAt"`
+}
+
+// ModerationQueueItem is a comment awaiting moderation with its post for context.
+type ModerationQueueItem struct {
+	Comment
+	PostTitle    string `json:"postTitle"`
+	PostCategory string `json:"postCategory"`
 }
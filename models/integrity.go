@@ -0,0 +1,42 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+const (
+	// IntegrityMissingFile: an /uploads reference whose file is not on disk
+	IntegrityMissingFile = "missing_file"
+	// IntegrityMissingRecord: an /uploads reference without an UploadedImage record
+	IntegrityMissingRecord = "missing_record"
+	// IntegrityPendingRecord: a referenced image still 'pending', so the cleanup job will remove it
+	IntegrityPendingRecord = "pending_record"
+	// IntegrityDeletedPostLink: an internal link to a post in the trash
+	IntegrityDeletedPostLink = "deleted_post_link"
+	// IntegrityMissingPostLink: an internal link to a post that does not exist
+	IntegrityMissingPostLink = "missing_post_link"
+)
+
+const (
+	IntegritySourcePost        = "post"
+	IntegritySourceLandingPage = "landing_page"
+)
+
+type IntegrityIssue struct {
+	Type        string `json:"type" bson:"type"`
+	Source      string `json:"source" bson:"source"`
+	SourceID    string `json:"source_id" bson:"source_id"`
+	SourceTitle string `json:"source_title,omitempty" bson:"source_title,omitempty"`
+	Field       string `json:"field" bson:"field"`
+	URL         string `json:"url" bson:"url"`
+}
+
+type IntegrityReport struct {
+	ID                primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	CheckedAt         time.Time          `json:"checked_at" bson:"checked_at"`
+	PostsScanned      int                `json:"posts_scanned" bson:"posts_scanned"`
+	ReferencesChecked int                `json:"references_checked" bson:"references_checked"`
+	Counts            map[string]int     `json:"counts" bson:"counts"`
+	Issues            []IntegrityIssue   `json:"issues" bson:"issues"`
+}
@@ -5,21 +5,105 @@ import (
 	"time"
 )
 
+const (
+	PostStatusActive  = "active"
+	PostStatusDraft   = "draft"
+	PostStatusDeleted = StatusDeleted
+)
+
+const (
+	// VisibilityPublic posts are readable by everyone
+	VisibilityPublic = "public"
+	// VisibilityMembers posts are readable by logged-in users only
+	VisibilityMembers = "members"
+	// VisibilityClasses posts are readable by parents of the listed classes only
+	VisibilityClasses = "classes"
+)
+
+const (
+	ContentFormatHTML     = "html"
+	ContentFormatMarkdown = "markdown"
+)
+
 type Post struct {
 	ID          primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
 	Title       string             `json:"title" bson:"title"`
-	Content     string             `json:"content" bson:"content"`
+	Content     string             `json:"content,omitempty" bson:"content"`
 	HeaderImage string             `json:"header_image" bson:"header_image"`
 	Category    string             `json:"category" bson:"category"`
+	Tags        []string           `json:"tags" bson:"tags"`
 	Author      string             `json:"author" bson:"author"`
 	CreateAt    time.Time          `json:"create_at" bson:"create_at"`
 	UpdateAt    time.Time          `json:"update_at" bson:"update_at"`
 	Status      string             `json:"status" bson:"status"`
+	ViewCount   int64              `json:"view_count" bson:"view_count"`
+	IsFeatured  bool               `json:"is_featured" bson:"is_featured"`
+	IsPinned    bool               `json:"is_pinned" bson:"is_pinned"`
+	PinOrder    int                `json:"pin_order" bson:"pin_order"`
+	PinnedUntil *time.Time         `json:"pinned_until,omitempty" bson:"pinned_until,omitempty"`
+
+	// Published comments, kept up to date by service.RefreshPostCommentStats
+	CommentCount  int        `json:"comment_count" bson:"comment_count"`
+	LastCommentAt *time.Time `json:"last_comment_at,omitempty" bson:"last_comment_at,omitempty"`
+
+	// ContentFormat is "html" or "markdown". Markdown posts keep the editor's
+	// source in ContentSource and the rendered, sanitised HTML in Content.
+	ContentFormat string `json:"content_format" bson:"content_format"`
+	ContentSource string `json:"content_source,omitempty" bson:"content_source,omitempty"`
+
+	// Who may read the post; see the Visibility* constants. Staff read everything.
+	Visibility       string   `json:"visibility" bson:"visibility"`
+	VisibleToClasses []string `json:"visible_to_classes,omitempty" bson:"visible_to_classes,omitempty"`
+
+	// Set while the post is in the trash; PreviousStatus is restored on recovery
+	DeletedAt      *time.Time `json:"deleted_at,omitempty" bson:"deleted_at,omitempty"`
+	PreviousStatus string     `json:"-" bson:"previous_status,omitempty"`
+
+	// Generated on save from the content, unless overridden by an editor
+	Excerpt         string   `json:"excerpt" bson:"excerpt"`
+	WordCount       int      `json:"word_count" bson:"word_count"`
+	ReadingTime     int      `json:"reading_time" bson:"reading_time"`
+	Meta            PostMeta `json:"meta" bson:"meta"`
+	ExcerptOverride string   `json:"excerpt_override,omitempty" bson:"excerpt_override,omitempty"`
+	MetaOverrides   PostMeta `json:"meta_overrides" bson:"meta_overrides"`
+
+	// Incremented to revoke every preview link issued for the post
+	PreviewVersion int `json:"-" bson:"preview_version"`
+
+	// Identifies the post on the site it was imported from, e.g. "wordpress:<guid>"
+	LegacyID string `json:"legacy_id,omitempty" bson:"legacy_id,omitempty"`
+}
+
+// PostMeta holds the Open Graph / Twitter card metadata of a post.
+type PostMeta struct {
+	Title       string `json:"title,omitempty" bson:"title,omitempty"`
+	Description string `json:"description,omitempty" bson:"description,omitempty"`
+	Image       string `json:"image,omitempty" bson:"image,omitempty"`
+	TwitterCard string `json:"twitter_card,omitempty" bson:"twitter_card,omitempty"`
+}
+
+// PinActive reports whether the post is pinned and the pin has not expired at t.
+func (p Post) PinActive(t time.Time) bool {
+	return p.IsPinned && (p.PinnedUntil == nil || p.PinnedUntil.After(t))
+}
+
+type PinPostPayload struct {
+	Order       *int       `json:"order,omitempty"`
+	PinnedUntil *time.Time `json:"pinned_until,omitempty"`
+}
+
+type FeaturePostPayload struct {
+	Featured bool `json:"featured"`
+}
+
+type ReorderPinnedPostsPayload struct {
+	PostIDs []string `json:"post_ids"`
 }
 
 type PostDetailResponse struct {
-	Post   Post            `json:"post"`
-	Images []UploadedImage `json:"images"`
+	Post        Post            `json:"post"`
+	Images      []UploadedImage `json:"images"`
+	Attachments []Attachment    `json:"attachments"`
 }
 
 type LandingPageContent struct {
@@ -28,3 +112,36 @@ type LandingPageContent struct {
 	Content   map[string]interface{} `bson:"content" json:"content"`
 	UpdatedAt time.Time              `bson:"updated_at" json:"updated_at"`
 }
+
+type CreatePreviewLinkPayload struct {
+	ExpiresInHours int `json:"expires_in_hours"`
+}
+
+type PreviewLinkResponse struct {
+	Token     string    `json:"token"`
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+const (
+	BulkActionSetStatus   = "set_status"
+	BulkActionSetCategory = "set_category"
+	BulkActionAddTags     = "add_tags"
+	BulkActionRemoveTags  = "remove_tags"
+	BulkActionDelete      = "delete"
+	BulkActionRestore     = "restore"
+)
+
+type BulkPostActionPayload struct {
+	PostIDs  []string `json:"post_ids"`
+	Action   string   `json:"action"`
+	Status   string   `json:"status,omitempty"`
+	Category string   `json:"category,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+}
+
+type BulkPostActionResult struct {
+	ID      string `json:"id"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
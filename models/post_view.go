@@ -0,0 +1,28 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// PostView marks that a visitor has already been counted for a post on a given day.
+type PostView struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	PostID    primitive.ObjectID `bson:"post_id" json:"post_id"`
+	VisitorID string             `bson:"visitor_id" json:"visitor_id"`
+	Day       string             `bson:"day" json:"day"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// PostViewDaily holds the number of distinct views a post received on a day (YYYY-MM-DD, UTC).
+type PostViewDaily struct {
+	ID     primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	PostID primitive.ObjectID `bson:"post_id" json:"post_id"`
+	Day    string             `bson:"day" json:"day"`
+	Views  int64              `bson:"views" json:"views"`
+}
+
+type PopularPost struct {
+	Post  Post  `json:"post"`
+	Views int64 `json:"views"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type RelatedPostScore struct {
+	PostID primitive.ObjectID `bson:"post_id" json:"post_id"`
+	Score  float64            `bson:"score" json:"score"`
+}
+
+// RelatedPosts caches the recommendations computed for a post.
+type RelatedPosts struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	PostID     primitive.ObjectID `bson:"post_id" json:"post_id"`
+	Related    []RelatedPostScore `bson:"related" json:"related"`
+	ComputedAt time.Time          `bson:"computed_at" json:"computed_at"`
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+const (
+	TourBookingBooked    = "booked"
+	TourBookingCancelled = "cancelled"
+)
+
+// TourSlotRule is a weekly recurring campus tour, e.g. every Tuesday 09:00
+// at one campus for up to 5 families. Slots are generated from it on demand.
+type TourSlotRule struct {
+	ID     primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	Campus string             `json:"campus" bson:"campus"`
+	// Weekday is 0 for Sunday to 6 for Saturday
+	Weekday         int       `json:"weekday" bson:"weekday"`
+	StartTime       string    `json:"start_time" bson:"start_time"`
+	DurationMinutes int       `json:"duration_minutes" bson:"duration_minutes"`
+	Capacity        int       `json:"capacity" bson:"capacity"`
+	Active          bool      `json:"active" bson:"active"`
+	CreatedAt       time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// TourSlot is one occurrence of a rule. Booked is only changed with
+// conditional updates so that a slot is never booked beyond its capacity.
+type TourSlot struct {
+	ID       primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	RuleID   primitive.ObjectID `json:"rule_id" bson:"rule_id"`
+	Campus   string             `json:"campus" bson:"campus"`
+	StartAt  time.Time          `json:"start_at" bson:"start_at"`
+	EndAt    time.Time          `json:"end_at" bson:"end_at"`
+	Capacity int                `json:"capacity" bson:"capacity"`
+	Booked   int                `json:"booked" bson:"booked"`
+}
+
+// TourBooking reserves a place on a tour for a candidate's family.
+type TourBooking struct {
+	ID           primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	SlotID       primitive.ObjectID `json:"slot_id" bson:"slot_id"`
+	CandidateID  primitive.ObjectID `json:"candidate_id" bson:"candidate_id"`
+	Campus       string             `json:"campus" bson:"campus"`
+	StartAt      time.Time          `json:"start_at" bson:"start_at"`
+	Status       string             `json:"status" bson:"status"`
+	CancelReason string             `json:"cancel_reason,omitempty" bson:"cancel_reason,omitempty"`
+	CreatedAt    time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt    time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// CandidateTour is the candidate's current tour booking, kept on the candidate for listings.
+type CandidateTour struct {
+	BookingID primitive.ObjectID `json:"booking_id" bson:"booking_id"`
+	Campus    string             `json:"campus" bson:"campus"`
+	StartAt   time.Time          `json:"start_at" bson:"start_at"`
+}
+
+type TourSlotRulePayload struct {
+	Campus          string `json:"campus"`
+	Weekday         int    `json:"weekday"`
+	StartTime       string `json:"start_time"`
+	DurationMinutes int    `json:"duration_minutes"`
+	Capacity        int    `json:"capacity"`
+	Active          *bool  `json:"active"`
+}
+
+type BookTourPayload struct {
+	CandidateID string `json:"candidate_id"`
+	SlotID      string `json:"slot_id"`
+}
+
+type RescheduleTourPayload struct {
+	SlotID string `json:"slot_id"`
+}
+
+type CancelTourPayload struct {
+	Reason string `json:"reason"`
+}
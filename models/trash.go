@@ -0,0 +1,24 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// StatusDeleted is the status of soft-deleted posts, candidates and comments.
+const StatusDeleted = "deleted"
+
+const (
+	TrashTypePost      = "post"
+	TrashTypeCandidate = "candidate"
+	TrashTypeComment   = "comment"
+)
+
+// TrashItem is a soft-deleted document as listed in the trash bin.
+type TrashItem struct {
+	ID        primitive.ObjectID `json:"id"`
+	Type      string             `json:"type"`
+	Title     string             `json:"title"`
+	DeletedAt time.Time          `json:"deleted_at"`
+	PurgeAt   time.Time          `json:"purge_at"`
+}
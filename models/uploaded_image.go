@@ -19,5 +19,6 @@ type UploadedImage struct {
 	URL       string             `bson:"url" json:"url"`
 	Status    ImageStatus        `bson:"status" json:"status"`
 	Style     string             `bson:"style,omitempty" json:"style,omitempty"`
+	SourceURL string             `bson:"source_url,omitempty" json:"source_url,omitempty"`
 	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
 }
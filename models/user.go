@@ -5,6 +5,18 @@ import (
 	"time"
 )
 
+const (
+	RoleAdmin   = "admin"
+	RoleStaff   = "staff"
+	RoleTeacher = "teacher"
+	RoleParent  = "parent"
+)
+
+// IsStaffRole reports whether a role belongs to school staff rather than to a parent.
+func IsStaffRole(role string) bool {
+	return role == RoleAdmin || role == RoleStaff || role == RoleTeacher
+}
+
 type User struct {
 	ID       primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	Username string             `bson:"username" json:"username"`
@@ -14,4 +26,12 @@ type User struct {
 	CreateAt time.Time          `bson:"create_at" json:"date_created"`
 	UpdateAt time.Time          `bson:"update_at" json:"update_at"`
 	IsActive bool               `bson:"is_active" json:"is_active"`
+
+	// Classes of a parent's children, for class-restricted posts
+	Classes []string `bson:"classes,omitempty" json:"classes,omitempty"`
+}
+
+// SetUserClassesPayload replaces the classes of a parent's children.
+type SetUserClassesPayload struct {
+	Classes []string `json:"classes"`
 }
@@ -0,0 +1,26 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+const (
+	ImportStatusImported = "imported"
+	ImportStatusSkipped  = "skipped"
+	ImportStatusFailed   = "failed"
+)
+
+type WordPressImportItem struct {
+	LegacyID string              `json:"legacy_id"`
+	Title    string              `json:"title"`
+	Status   string              `json:"status"`
+	PostID   *primitive.ObjectID `json:"post_id,omitempty"`
+	Error    string              `json:"error,omitempty"`
+}
+
+type WordPressImportResult struct {
+	Imported    int                   `json:"imported"`
+	Skipped     int                   `json:"skipped"`
+	Failed      int                   `json:"failed"`
+	MediaCopied int                   `json:"media_copied"`
+	MediaFailed []string              `json:"media_failed"`
+	Items       []WordPressImportItem `json:"items"`
+}
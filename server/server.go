@@ -19,6 +19,9 @@ func RegisterServer() {
 	if err != nil {
 		log.Fatalf("Could not initialize database: %v", err)
 	}
+	if err := database.EnsureIndexes(db); err != nil {
+		log.Printf("Warning: could not ensure database indexes: %v", err)
+	}
 
 	// Initialize router
 	r := h.RegisterHandlerV1(db)
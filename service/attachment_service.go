@@ -0,0 +1,148 @@
+package service
+
+import (
+	"amg-backend/config"
+	"amg-backend/models"
+	"bytes"
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+const (
+	// AttachmentDir is where attachments are stored. It is not served
+	// statically so that downloads always go through the download endpoint.
+	AttachmentDir = "./attachments"
+	// MaxAttachmentSize is the largest attachment accepted, in bytes.
+	MaxAttachmentSize = 20 * 1024 * 1024
+)
+
+var ErrUnsupportedAttachment = errors.New("unsupported attachment type")
+
+// Legacy Office documents (.doc, .xls, .ppt) are OLE2 compound files
+var oleSignature = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+
+var officeOpenXMLTypes = map[string]string{
+	".docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	".xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	".pptx": "application/vnd.openxmlformats-officedocument.presentationml.presentation",
+}
+
+var legacyOfficeTypes = map[string]string{
+	".doc": "application/msword",
+	".xls": "application/vnd.ms-excel",
+	".ppt": "application/vnd.ms-powerpoint",
+}
+
+// SniffAttachmentType checks the first bytes of a file against its extension
+// and returns the MIME type to serve it with. Only PDF and Office documents
+// are accepted.
+func SniffAttachmentType(head []byte, filename string) (string, error) {
+	ext := strings.ToLower(filepath.Ext(filename))
+	detected := http.DetectContentType(head)
+
+	switch {
+	case ext == ".pdf" && detected == "application/pdf":
+		return "application/pdf", nil
+	case officeOpenXMLTypes[ext] != "" && detected == "application/zip":
+		return officeOpenXMLTypes[ext], nil
+	case legacyOfficeTypes[ext] != "" && bytes.HasPrefix(head, oleSignature):
+		return legacyOfficeTypes[ext], nil
+	}
+	return "", ErrUnsupportedAttachment
+}
+
+// SanitizeFilename keeps the base name of an uploaded file without control
+// characters or path separators, so it can be sent back on download.
+func SanitizeFilename(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == '"' || r == '/' {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+	if name == "" || name == "." {
+		return "attachment"
+	}
+	return name
+}
+
+// LinkAttachments attaches the given attachments to a post and marks them as
+// used. Attachments previously linked to the post but missing from ids are
+// released back to 'pending' so that the cleanup job can remove them.
+// Attachments of another post are left alone and returned in skipped, as are
+// ids that do not exist.
+func LinkAttachments(db *mongo.Client, postID primitive.ObjectID, ids []primitive.ObjectID) (skipped []primitive.ObjectID, err error) {
+	collection := db.Database(config.DBName).Collection("Attachment")
+
+	_, err = collection.UpdateMany(context.TODO(),
+		bson.M{"post_id": postID, "_id": bson.M{"$nin": ids}},
+		bson.M{"$set": bson.M{"status": models.AttachmentStatusPending}, "$unset": bson.M{"post_id": ""}},
+	)
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+
+	_, err = collection.UpdateMany(context.TODO(),
+		bson.M{
+			"_id": bson.M{"$in": ids},
+			"$or": bson.A{
+				bson.M{"post_id": bson.M{"$exists": false}},
+				bson.M{"post_id": postID},
+			},
+		},
+		bson.M{"$set": bson.M{"post_id": postID, "status": models.AttachmentStatusUsed}},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	linked, err := collection.Distinct(context.TODO(), "_id", bson.M{"_id": bson.M{"$in": ids}, "post_id": postID})
+	if err != nil {
+		return nil, err
+	}
+	isLinked := make(map[primitive.ObjectID]bool, len(linked))
+	for _, value := range linked {
+		if id, ok := value.(primitive.ObjectID); ok {
+			isLinked[id] = true
+		}
+	}
+	for _, id := range ids {
+		if !isLinked[id] {
+			skipped = append(skipped, id)
+		}
+	}
+	return skipped, nil
+}
+
+// SetPostAttachmentsStatus changes the status of every attachment linked to a
+// post while keeping the link, e.g. when the post is deleted or recovered.
+func SetPostAttachmentsStatus(db *mongo.Client, postID primitive.ObjectID, status models.AttachmentStatus) error {
+	collection := db.Database(config.DBName).Collection("Attachment")
+	_, err := collection.UpdateMany(context.TODO(), bson.M{"post_id": postID}, bson.M{"$set": bson.M{"status": status}})
+	return err
+}
+
+// GetPostAttachments returns the attachments linked to a post, oldest first.
+func GetPostAttachments(db *mongo.Client, postID primitive.ObjectID) ([]models.Attachment, error) {
+	collection := db.Database(config.DBName).Collection("Attachment")
+	findOptions := options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}})
+	cursor, err := collection.Find(context.TODO(), bson.M{"post_id": postID}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	attachments := make([]models.Attachment, 0)
+	err = cursor.All(context.TODO(), &attachments)
+	return attachments, err
+}
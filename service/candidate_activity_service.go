@@ -0,0 +1,96 @@
+package service
+
+import (
+	"amg-backend/config"
+	"amg-backend/models"
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidActivityType = errors.New("activity type must be note, call or email")
+	ErrInvalidFollowUpDate = errors.New("invalid follow-up date")
+)
+
+// ParseActivityType normalises the type of an activity staff add by hand,
+// which must be a note, call or email.
+func ParseActivityType(value string) (string, error) {
+	kind := strings.ToLower(strings.TrimSpace(value))
+	if !models.IsManualCandidateActivity(kind) {
+		return "", ErrInvalidActivityType
+	}
+	return kind, nil
+}
+
+// ParseFollowUpDate accepts a date (2006-01-02, taken as local midnight) or
+// an RFC 3339 time. An empty string means no follow-up.
+func ParseFollowUpDate(value string) (*time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, ErrInvalidFollowUpDate
+	}
+	return &t, nil
+}
+
+// AddCandidateActivity appends an entry to a candidate's timeline and makes
+// it the candidate's latest activity.
+func AddCandidateActivity(db *mongo.Client, activity models.CandidateActivity) (models.CandidateActivity, error) {
+	activity.ID = primitive.NewObjectID()
+	if activity.CreatedAt.IsZero() {
+		activity.CreatedAt = time.Now()
+	}
+
+	database := db.Database(config.DBName)
+	if _, err := database.Collection("CandidateActivity").InsertOne(context.TODO(), activity); err != nil {
+		return activity, err
+	}
+
+	summary := models.CandidateActivitySummary{
+		Type:         activity.Type,
+		Channel:      activity.Channel,
+		AuthorName:   activity.AuthorName,
+		At:           activity.CreatedAt,
+		NextFollowUp: activity.NextFollowUp,
+	}
+	// Entries are not always added in order, e.g. a call logged afterwards
+	_, err := database.Collection("Candidate").UpdateOne(context.TODO(),
+		bson.M{
+			"_id": activity.CandidateID,
+			"$or": bson.A{
+				bson.M{"last_activity.at": bson.M{"$exists": false}},
+				bson.M{"last_activity.at": bson.M{"$lte": activity.CreatedAt}},
+			},
+		},
+		bson.M{"$set": bson.M{"last_activity": summary}},
+	)
+	return activity, err
+}
+
+// ListCandidateActivities returns a candidate's timeline, newest first.
+func ListCandidateActivities(db *mongo.Client, candidateID primitive.ObjectID) ([]models.CandidateActivity, error) {
+	cursor, err := db.Database(config.DBName).Collection("CandidateActivity").Find(context.TODO(),
+		bson.M{"candidate_id": candidateID},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	activities := make([]models.CandidateActivity, 0)
+	if err := cursor.All(context.TODO(), &activities); err != nil {
+		return nil, err
+	}
+	return activities, nil
+}
@@ -0,0 +1,542 @@
+package service
+
+import (
+	"amg-backend/config"
+	"amg-backend/models"
+	"context"
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	ErrMergeSameCandidate = errors.New("cannot merge a candidate into itself")
+	ErrCandidateMerged    = errors.New("candidate was merged into another one")
+)
+
+// vietnameseBase maps accented Vietnamese letters to their base letter, so
+// names typed without a Vietnamese keyboard still match.
+var vietnameseBase = func() map[rune]rune {
+	groups := map[rune]string{
+		'a': "àáảãạăằắẳẵặâầấẩẫậ",
+		'e': "èéẻẽẹêềếểễệ",
+		'i': "ìíỉĩị",
+		'o': "òóỏõọôồốổỗộơờớởỡợ",
+		'u': "ùúủũụưừứửữự",
+		'y': "ỳýỷỹỵ",
+		'd': "đ",
+	}
+	base := make(map[rune]rune)
+	for letter, accented := range groups {
+		for _, r := range accented {
+			base[r] = letter
+		}
+	}
+	return base
+}()
+
+// normaliseName lower-cases a name, drops accents and punctuation and
+// collapses whitespace.
+func normaliseName(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	for i, word := range words {
+		words[i] = strings.Map(func(r rune) rune {
+			if b, ok := vietnameseBase[r]; ok {
+				return b
+			}
+			return r
+		}, word)
+	}
+	return strings.Join(words, " ")
+}
+
+// normalisePhoneKey reduces a phone number to its national digits, so
+// "+84 912 345 678" and "0912.345.678" compare equal.
+func normalisePhoneKey(phone string) string {
+	if e164, err := NormaliseVietnamesePhone(phone); err == nil {
+		return "0" + strings.TrimPrefix(e164, "+84")
+	}
+	// Numbers saved before phones were validated
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, phone)
+	if len(digits) < 9 {
+		return ""
+	}
+	return digits
+}
+
+// normaliseDOBKey turns a date of birth into YYYY-MM-DD when it can be read.
+func normaliseDOBKey(dob string) string {
+	if t, err := ParseCandidateDOB(dob); err == nil {
+		return t.Format("2006-01-02")
+	}
+	return strings.TrimSpace(dob)
+}
+
+// CandidateMatchKeysOf computes the keys duplicate detection compares.
+func CandidateMatchKeysOf(candidate models.Candidate) *models.CandidateMatchKeys {
+	keys := &models.CandidateMatchKeys{
+		Phone:      normalisePhoneKey(candidate.Phone),
+		ParentName: normaliseName(candidate.ParentName),
+		DOB:        normaliseDOBKey(candidate.DateOfBirth),
+	}
+	if *keys == (models.CandidateMatchKeys{}) {
+		return nil
+	}
+	return keys
+}
+
+// FindCandidateDuplicates returns the candidates that are likely the same
+// family as candidate: the same phone number, or the same parent name and
+// child's date of birth. Trashed and spam candidates are not considered.
+func FindCandidateDuplicates(db *mongo.Client, candidate models.Candidate) ([]models.Candidate, error) {
+	matches := make([]models.Candidate, 0)
+	keys := CandidateMatchKeysOf(candidate)
+	if keys == nil {
+		return matches, nil
+	}
+
+	or := bson.A{}
+	if keys.Phone != "" {
+		or = append(or, bson.M{"match_keys.phone": keys.Phone})
+	}
+	if keys.ParentName != "" && keys.DOB != "" {
+		or = append(or, bson.M{"match_keys.parent_name": keys.ParentName, "match_keys.dob": keys.DOB})
+	}
+	if len(or) == 0 {
+		return matches, nil
+	}
+
+	cursor, err := db.Database(config.DBName).Collection("Candidate").Find(context.TODO(),
+		bson.M{
+			"_id":    bson.M{"$ne": candidate.ID},
+			"status": bson.M{"$nin": bson.A{models.StatusDeleted, models.CandidateStatusSpam}},
+			"$or":    or,
+		},
+		options.Find().SetSort(bson.D{{Key: "create_at", Value: 1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(context.TODO(), &matches); err != nil {
+		return nil, err
+	}
+	return matches, nil
+}
+
+// RefreshCandidateMatchKeys recomputes the match keys of a candidate after its
+// contact details were edited.
+func RefreshCandidateMatchKeys(db *mongo.Client, id primitive.ObjectID) error {
+	candidate, err := FindCandidate(db, id)
+	if err != nil {
+		return err
+	}
+	update := bson.M{"$unset": bson.M{"match_keys": ""}}
+	if keys := CandidateMatchKeysOf(candidate); keys != nil {
+		update = bson.M{"$set": bson.M{"match_keys": keys}}
+	}
+	_, err = db.Database(config.DBName).Collection("Candidate").UpdateOne(context.TODO(), bson.M{"_id": id}, update)
+	return err
+}
+
+// BackfillCandidateMatchKeys computes the match keys of candidates saved
+// before duplicate detection existed, so that new forms are compared with
+// them too. It returns how many candidates it updated. Candidates without
+// any contact details get empty keys so that they are not looked at again.
+func BackfillCandidateMatchKeys(db *mongo.Client) (int, error) {
+	collection := db.Database(config.DBName).Collection("Candidate")
+	cursor, err := collection.Find(context.TODO(),
+		bson.M{"match_keys": bson.M{"$exists": false}, "status": bson.M{"$ne": models.StatusDeleted}},
+		options.Find().SetProjection(bson.M{"phone": 1, "parent_name": 1, "dob": 1}),
+	)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(context.TODO())
+
+	updated := 0
+	for cursor.Next(context.TODO()) {
+		var candidate models.Candidate
+		if err := cursor.Decode(&candidate); err != nil {
+			return updated, err
+		}
+		keys := CandidateMatchKeysOf(candidate)
+		if keys == nil {
+			keys = &models.CandidateMatchKeys{}
+		}
+		if _, err := collection.UpdateOne(context.TODO(),
+			bson.M{"_id": candidate.ID, "match_keys": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"match_keys": keys}},
+		); err != nil {
+			return updated, err
+		}
+		updated++
+	}
+	return updated, cursor.Err()
+}
+
+// ListPossibleDuplicates returns the candidates flagged as likely duplicates,
+// newest first, each with the candidates it matched. Matches that have since
+// been trashed or purged are left out, and so are flags with none left.
+func ListPossibleDuplicates(db *mongo.Client) ([]models.CandidateDuplicates, error) {
+	collection := db.Database(config.DBName).Collection("Candidate")
+	cursor, err := collection.Find(context.TODO(),
+		bson.M{
+			"status":                bson.M{"$ne": models.StatusDeleted},
+			"possible_duplicates.0": bson.M{"$exists": true},
+		},
+		options.Find().SetSort(bson.D{{Key: "create_at", Value: -1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	var flagged []models.Candidate
+	if err := cursor.All(context.TODO(), &flagged); err != nil {
+		return nil, err
+	}
+
+	ids := make([]primitive.ObjectID, 0)
+	for _, candidate := range flagged {
+		for _, id := range candidate.PossibleDuplicates {
+			if !containsObjectID(ids, id) {
+				ids = append(ids, id)
+			}
+		}
+	}
+	byID := make(map[primitive.ObjectID]models.Candidate)
+	if len(ids) > 0 {
+		cursor, err := collection.Find(context.TODO(),
+			bson.M{"_id": bson.M{"$in": ids}, "status": bson.M{"$ne": models.StatusDeleted}},
+		)
+		if err != nil {
+			return nil, err
+		}
+		var live []models.Candidate
+		if err := cursor.All(context.TODO(), &live); err != nil {
+			return nil, err
+		}
+		for _, candidate := range live {
+			byID[candidate.ID] = candidate
+		}
+	}
+
+	result := make([]models.CandidateDuplicates, 0, len(flagged))
+	for _, candidate := range flagged {
+		matches := make([]models.Candidate, 0, len(candidate.PossibleDuplicates))
+		for _, id := range candidate.PossibleDuplicates {
+			if match, ok := byID[id]; ok {
+				matches = append(matches, match)
+			}
+		}
+		if len(matches) == 0 {
+			continue
+		}
+		sort.Slice(matches, func(i, j int) bool { return matches[i].CreateAt.Before(matches[j].CreateAt) })
+		result = append(result, models.CandidateDuplicates{Candidate: candidate, Matches: matches})
+	}
+	return result, nil
+}
+
+// DismissCandidateDuplicates clears the duplicate flag of a candidate staff
+// checked and found to be a different family.
+func DismissCandidateDuplicates(db *mongo.Client, id primitive.ObjectID) error {
+	result, err := db.Database(config.DBName).Collection("Candidate").UpdateOne(context.TODO(),
+		bson.M{"_id": id, "status": bson.M{"$ne": models.StatusDeleted}},
+		bson.M{"$unset": bson.M{"possible_duplicates": ""}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrCandidateNotFound
+	}
+	return nil
+}
+
+// candidateStageRank orders statuses by how far the family got, so a merge
+// keeps the furthest one. A family who sent the form again is no longer lost.
+func candidateStageRank(status string) int {
+	switch CandidateStage(status) {
+	case models.CandidateStatusSpam:
+		return -2
+	case models.CandidateStatusDeclined, models.CandidateStatusLost:
+		return -1
+	case models.CandidateStatusContacted:
+		return 1
+	case models.CandidateStatusTourScheduled:
+		return 2
+	case models.CandidateStatusApplied:
+		return 3
+	case models.CandidateStatusOffered:
+		return 4
+	case models.CandidateStatusEnrolled:
+		return 5
+	}
+	return 0
+}
+
+// moreComplete picks the value to keep of two versions of a field: the kept
+// one unless it is empty, or when preferLonger, whichever says more.
+func moreComplete(kept string, other string, preferLonger bool) string {
+	kept, other = strings.TrimSpace(kept), strings.TrimSpace(other)
+	if kept == "" || (preferLonger && utf8.RuneCountInString(other) > utf8.RuneCountInString(kept)) {
+		return other
+	}
+	return kept
+}
+
+// MergeCandidates folds a duplicate into the candidate that is kept. Empty
+// fields are filled from the duplicate, names and address keep the fuller
+// version, and the candidate takes the furthest funnel stage of the two with
+// the earliest date each stage was entered. The duplicate's timeline and tour
+// booking move over (if both have a tour, the duplicate's is cancelled), and
+// the duplicate goes to the trash marked as merged.
+//
+// The duplicate is marked first and every later step can be repeated, so a
+// merge that failed part-way is completed by running it again.
+func MergeCandidates(db *mongo.Client, keepID primitive.ObjectID, duplicateID primitive.ObjectID, authorID string, authorName string) (models.Candidate, error) {
+	if keepID == duplicateID {
+		return models.Candidate{}, ErrMergeSameCandidate
+	}
+	kept, err := FindCandidate(db, keepID)
+	if err != nil {
+		return kept, err
+	}
+	if kept.MergedInto != nil {
+		return kept, ErrCandidateMerged
+	}
+	duplicate, err := FindCandidate(db, duplicateID)
+	if err != nil {
+		return kept, err
+	}
+	if duplicate.MergedInto != nil && *duplicate.MergedInto != keepID {
+		return kept, ErrCandidateMerged
+	}
+
+	database := db.Database(config.DBName)
+	candidates := database.Collection("Candidate")
+	// Claim the duplicate so it cannot be merged elsewhere meanwhile
+	result, err := candidates.UpdateOne(context.TODO(),
+		bson.M{"_id": duplicateID, "$or": bson.A{
+			bson.M{"merged_into": bson.M{"$exists": false}},
+			bson.M{"merged_into": keepID},
+		}},
+		bson.M{"$set": bson.M{"merged_into": keepID}},
+	)
+	if err != nil {
+		return kept, err
+	}
+	if result.MatchedCount == 0 {
+		return kept, ErrCandidateMerged
+	}
+
+	merged := mergeCandidateFields(kept, duplicate)
+
+	// The booking is found by candidate, so a repeated run sees it already moved
+	var keptBooking models.TourBooking
+	err = database.Collection("TourBooking").FindOne(context.TODO(),
+		bson.M{"candidate_id": keepID, "status": models.TourBookingBooked},
+	).Decode(&keptBooking)
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		var moved models.TourBooking
+		err := database.Collection("TourBooking").FindOneAndUpdate(context.TODO(),
+			bson.M{"candidate_id": duplicateID, "status": models.TourBookingBooked},
+			bson.M{"$set": bson.M{"candidate_id": keepID, "updated_at": time.Now()}},
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&moved)
+		if err == nil {
+			merged.Tour = &models.CandidateTour{BookingID: moved.ID, Campus: moved.Campus, StartAt: moved.StartAt}
+		} else if !errors.Is(err, mongo.ErrNoDocuments) {
+			return kept, err
+		}
+	case err != nil:
+		return kept, err
+	default:
+		merged.Tour = &models.CandidateTour{BookingID: keptBooking.ID, Campus: keptBooking.Campus, StartAt: keptBooking.StartAt}
+		reason := "merged into candidate " + keepID.Hex()
+		if err := CancelCandidateTours(db, duplicateID, reason, authorID, authorName); err != nil {
+			return kept, err
+		}
+	}
+
+	if _, err := database.Collection("CandidateActivity").UpdateMany(context.TODO(),
+		bson.M{"candidate_id": duplicateID},
+		bson.M{"$set": bson.M{"candidate_id": keepID}},
+	); err != nil {
+		return kept, err
+	}
+
+	if _, err := candidates.UpdateOne(context.TODO(), bson.M{"_id": keepID}, mergedCandidateUpdate(merged)); err != nil {
+		return kept, err
+	}
+	if err := repointDuplicateFlags(db, duplicateID, keepID); err != nil {
+		return kept, err
+	}
+	if err := recordCandidateMerge(db, keepID, duplicate, authorID, authorName); err != nil {
+		return kept, err
+	}
+
+	if _, err := candidates.UpdateOne(context.TODO(),
+		bson.M{"_id": duplicateID},
+		bson.M{"$unset": bson.M{"tour": "", "possible_duplicates": "", "last_activity": ""}},
+	); err != nil {
+		return kept, err
+	}
+	if _, err := MoveToTrash(db, models.TrashTypeCandidate, duplicateID); err != nil {
+		return kept, err
+	}
+	return FindCandidate(db, keepID)
+}
+
+// mergeCandidateFields combines the details of two candidates. Applied again
+// to its own result and the same duplicate it gives the same candidate.
+func mergeCandidateFields(kept models.Candidate, duplicate models.Candidate) models.Candidate {
+	merged := kept
+	merged.StudentName = moreComplete(kept.StudentName, duplicate.StudentName, true)
+	merged.ParentName = moreComplete(kept.ParentName, duplicate.ParentName, true)
+	merged.Address = moreComplete(kept.Address, duplicate.Address, true)
+	merged.Gender = moreComplete(kept.Gender, duplicate.Gender, false)
+	merged.DateOfBirth = moreComplete(kept.DateOfBirth, duplicate.DateOfBirth, false)
+	merged.Phone = moreComplete(kept.Phone, duplicate.Phone, false)
+	if merged.DateOfBirth != kept.DateOfBirth {
+		merged.BirthDate, merged.AgeGroup = duplicate.BirthDate, duplicate.AgeGroup
+	}
+	if duplicate.CreateAt.Before(merged.CreateAt) {
+		merged.CreateAt = duplicate.CreateAt
+	}
+	if candidateStageRank(duplicate.Status) > candidateStageRank(kept.Status) {
+		merged.Status = duplicate.Status
+		merged.ClosedReason = duplicate.ClosedReason
+	}
+	merged.StageDates = make(map[string]time.Time)
+	for _, dates := range []map[string]time.Time{kept.StageDates, duplicate.StageDates} {
+		for stage, at := range dates {
+			if current, ok := merged.StageDates[stage]; !ok || at.Before(current) {
+				merged.StageDates[stage] = at
+			}
+		}
+	}
+	merged.PossibleDuplicates = nil
+	for _, ids := range [][]primitive.ObjectID{kept.PossibleDuplicates, duplicate.PossibleDuplicates} {
+		for _, id := range ids {
+			if id != kept.ID && id != duplicate.ID && !containsObjectID(merged.PossibleDuplicates, id) {
+				merged.PossibleDuplicates = append(merged.PossibleDuplicates, id)
+			}
+		}
+	}
+	return merged
+}
+
+// mergedCandidateUpdate is the update that stores a merged candidate.
+func mergedCandidateUpdate(merged models.Candidate) bson.M {
+	set := bson.M{
+		"student_name": merged.StudentName,
+		"parent_name":  merged.ParentName,
+		"address":      merged.Address,
+		"gender":       merged.Gender,
+		"dob":          merged.DateOfBirth,
+		"phone":        merged.Phone,
+		"status":       merged.Status,
+		"stage_dates":  merged.StageDates,
+		"create_at":    merged.CreateAt,
+		"update_at":    time.Now(),
+	}
+	unset := bson.M{}
+	if keys := CandidateMatchKeysOf(merged); keys != nil {
+		set["match_keys"] = keys
+	} else {
+		unset["match_keys"] = ""
+	}
+	if merged.BirthDate != nil {
+		set["birth_date"] = merged.BirthDate
+		set["age_group"] = merged.AgeGroup
+	}
+	if merged.ClosedReason != "" {
+		set["closed_reason"] = merged.ClosedReason
+	} else {
+		unset["closed_reason"] = ""
+	}
+	if merged.Tour != nil {
+		set["tour"] = merged.Tour
+	} else {
+		unset["tour"] = ""
+	}
+	if len(merged.PossibleDuplicates) > 0 {
+		set["possible_duplicates"] = merged.PossibleDuplicates
+	} else {
+		unset["possible_duplicates"] = ""
+	}
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	return update
+}
+
+// recordCandidateMerge adds the merge to the kept candidate's timeline, unless
+// an earlier run of the same merge already did.
+func recordCandidateMerge(db *mongo.Client, keepID primitive.ObjectID, duplicate models.Candidate, authorID string, authorName string) error {
+	content := fmt.Sprintf("Merged with the form for %s sent on %s (%s)",
+		duplicate.StudentName, duplicate.CreateAt.In(TourLocation()).Format("02/01/2006 15:04"), duplicate.ID.Hex())
+	count, err := db.Database(config.DBName).Collection("CandidateActivity").CountDocuments(context.TODO(),
+		bson.M{"candidate_id": keepID, "type": models.CandidateActivityMerge, "content": content},
+	)
+	if err != nil || count > 0 {
+		return err
+	}
+	_, err = AddCandidateActivity(db, models.CandidateActivity{
+		CandidateID: keepID,
+		Type:        models.CandidateActivityMerge,
+		Content:     content,
+		AuthorID:    authorID,
+		AuthorName:  authorName,
+	})
+	return err
+}
+
+// repointDuplicateFlags makes candidates flagged as duplicates of a merged
+// candidate point at the candidate it was merged into.
+func repointDuplicateFlags(db *mongo.Client, from primitive.ObjectID, to primitive.ObjectID) error {
+	collection := db.Database(config.DBName).Collection("Candidate")
+	if _, err := collection.UpdateMany(context.TODO(),
+		bson.M{"possible_duplicates": from, "_id": bson.M{"$ne": to}},
+		bson.M{"$addToSet": bson.M{"possible_duplicates": to}},
+	); err != nil {
+		return err
+	}
+	if _, err := collection.UpdateMany(context.TODO(),
+		bson.M{"possible_duplicates": from},
+		bson.M{"$pull": bson.M{"possible_duplicates": from}},
+	); err != nil {
+		return err
+	}
+	_, err := collection.UpdateMany(context.TODO(),
+		bson.M{"possible_duplicates": bson.M{"$size": 0}},
+		bson.M{"$unset": bson.M{"possible_duplicates": ""}},
+	)
+	return err
+}
+
+func containsObjectID(ids []primitive.ObjectID, id primitive.ObjectID) bool {
+	for _, other := range ids {
+		if other == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"amg-backend/config"
+	"amg-backend/models"
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"strings"
+	"time"
+)
+
+var (
+	ErrCandidateNotFound      = errors.New("candidate not found")
+	ErrUnknownCandidateStatus = errors.New("unknown candidate status")
+	ErrIllegalTransition      = errors.New("transition not allowed")
+	ErrClosedReasonRequired   = errors.New("a reason is required to close a candidate")
+	ErrCandidateChanged       = errors.New("candidate changed concurrently")
+)
+
+// candidateTransitions lists the stages a candidate can move to from each
+// stage. Steps may be skipped, e.g. families who apply without a tour, and
+// declined or lost candidates can be reopened as contacted.
+var candidateTransitions = map[string][]string{
+	models.CandidateStatusNew: {
+		models.CandidateStatusContacted, models.CandidateStatusTourScheduled, models.CandidateStatusApplied,
+		models.CandidateStatusLost, models.CandidateStatusSpam,
+	},
+	models.CandidateStatusContacted: {
+		models.CandidateStatusTourScheduled, models.CandidateStatusApplied, models.CandidateStatusLost,
+	},
+	models.CandidateStatusTourScheduled: {
+		models.CandidateStatusContacted, models.CandidateStatusApplied, models.CandidateStatusLost,
+	},
+	models.CandidateStatusApplied: {
+		models.CandidateStatusOffered, models.CandidateStatusDeclined, models.CandidateStatusLost,
+	},
+	models.CandidateStatusOffered: {
+		models.CandidateStatusEnrolled, models.CandidateStatusDeclined, models.CandidateStatusLost,
+	},
+	models.CandidateStatusEnrolled: {},
+	models.CandidateStatusDeclined: {models.CandidateStatusContacted},
+	models.CandidateStatusLost:     {models.CandidateStatusContacted},
+	// Staff release candidates the filters flagged by mistake
+	models.CandidateStatusSpam: {models.CandidateStatusNew},
+}
+
+// IsClosedCandidateStatus reports whether the candidate has left the funnel
+// and needs a reason for it.
+func IsClosedCandidateStatus(status string) bool {
+	return status == models.CandidateStatusDeclined || status == models.CandidateStatusLost
+}
+
+// CandidateStage returns the funnel stage of a stored status. Statuses from
+// before the funnel existed, such as "recovered", count as new.
+func CandidateStage(status string) string {
+	if _, ok := candidateTransitions[status]; ok {
+		return status
+	}
+	return models.CandidateStatusNew
+}
+
+// NextCandidateStatuses lists the stages a candidate in status can move to.
+func NextCandidateStatuses(status string) []string {
+	return candidateTransitions[CandidateStage(status)]
+}
+
+// CanTransitionCandidate reports whether a candidate may move from one status to another.
+func CanTransitionCandidate(from string, to string) bool {
+	for _, next := range NextCandidateStatuses(from) {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// FindCandidate loads a candidate that is not in the trash.
+func FindCandidate(db *mongo.Client, id primitive.ObjectID) (models.Candidate, error) {
+	var candidate models.Candidate
+	err := db.Database(config.DBName).Collection("Candidate").FindOne(context.TODO(),
+		bson.M{"_id": id, "status": bson.M{"$ne": models.StatusDeleted}},
+	).Decode(&candidate)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return candidate, ErrCandidateNotFound
+	}
+	return candidate, err
+}
+
+// TransitionCandidate moves a candidate to another stage of the admissions
+// funnel, recording when it entered it. Moves the funnel does not allow fail
+// with ErrIllegalTransition. The update only applies if the candidate is still
+// in the stage it was read in, so concurrent moves cannot skip a check. The
+// move is recorded in the candidate's timeline under the given author.
+func TransitionCandidate(db *mongo.Client, id primitive.ObjectID, to string, reason string, authorID string, authorName string) (models.Candidate, error) {
+	to = strings.TrimSpace(to)
+	if _, ok := candidateTransitions[to]; !ok {
+		return models.Candidate{}, ErrUnknownCandidateStatus
+	}
+	reason = strings.TrimSpace(reason)
+	if IsClosedCandidateStatus(to) && reason == "" {
+		return models.Candidate{}, ErrClosedReasonRequired
+	}
+
+	candidate, err := FindCandidate(db, id)
+	if err != nil {
+		return candidate, err
+	}
+	if !CanTransitionCandidate(candidate.Status, to) {
+		return candidate, ErrIllegalTransition
+	}
+	from := candidate.Status
+
+	now := time.Now()
+	set := bson.M{"status": to, "stage_dates." + to: now, "update_at": now}
+	update := bson.M{"$set": set}
+	if IsClosedCandidateStatus(to) {
+		set["closed_reason"] = reason
+	} else {
+		update["$unset"] = bson.M{"closed_reason": ""}
+	}
+
+	err = db.Database(config.DBName).Collection("Candidate").FindOneAndUpdate(context.TODO(),
+		bson.M{"_id": id, "status": from},
+		update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&candidate)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return candidate, ErrCandidateChanged
+	}
+	if err != nil {
+		return candidate, err
+	}
+
+	activity, err := AddCandidateActivity(db, models.CandidateActivity{
+		CandidateID: id,
+		Type:        models.CandidateActivityStatusChange,
+		Content:     reason,
+		AuthorID:    authorID,
+		AuthorName:  authorName,
+		CreatedAt:   now,
+		FromStatus:  from,
+		ToStatus:    to,
+	})
+	if err != nil {
+		log.Printf("Warning: could not record status change of candidate %s: %v", id.Hex(), err)
+	} else {
+		candidate.LastActivity = &models.CandidateActivitySummary{
+			Type:       activity.Type,
+			AuthorName: activity.AuthorName,
+			At:         activity.CreatedAt,
+		}
+	}
+
+	if IsClosedCandidateStatus(to) {
+		// The family is not coming, so their tour place goes back to others;
+		// the cancellation is added to the timeline like other tour changes
+		if err := CancelCandidateTours(db, id, "candidate "+to+": "+reason, authorID, authorName); err != nil {
+			log.Printf("Warning: could not cancel the tour of candidate %s: %v", id.Hex(), err)
+		} else if candidate.Tour != nil {
+			if reloaded, err := FindCandidate(db, id); err == nil {
+				candidate = reloaded
+			}
+		}
+	}
+	return candidate, nil
+}
@@ -0,0 +1,307 @@
+package service
+
+import (
+	"amg-backend/config"
+	"amg-backend/models"
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidPhone = errors.New("invalid Vietnamese phone number")
+	ErrInvalidDOB   = errors.New("invalid date of birth")
+)
+
+// FieldErrors maps the JSON name of each invalid field to what is wrong with it.
+type FieldErrors map[string]string
+
+func (e FieldErrors) Error() string {
+	fields := make([]string, 0, len(e))
+	for field := range e {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return "invalid " + strings.Join(fields, ", ")
+}
+
+// vietnameseMobilePrefixes are the three-digit prefixes of Vietnamese mobile
+// carriers: Viettel, Vinaphone, Mobifone, Vietnamobile, Gmobile, Itel and Reddi.
+var vietnameseMobilePrefixes = map[string]bool{
+	"032": true, "033": true, "034": true, "035": true, "036": true, "037": true, "038": true, "039": true,
+	"052": true, "055": true, "056": true, "058": true, "059": true,
+	"070": true, "076": true, "077": true, "078": true, "079": true,
+	"081": true, "082": true, "083": true, "084": true, "085": true, "086": true, "087": true, "088": true, "089": true,
+	"090": true, "091": true, "092": true, "093": true, "094": true, "096": true, "097": true, "098": true, "099": true,
+}
+
+// renumberedMobilePrefixes maps the eleven-digit mobile prefixes retired in
+// 2018 to their ten-digit replacements, as families still write the old ones.
+var renumberedMobilePrefixes = map[string]string{
+	"0162": "032", "0163": "033", "0164": "034", "0165": "035", "0166": "036", "0167": "037", "0168": "038", "0169": "039",
+	"0120": "070", "0121": "079", "0122": "077", "0126": "076", "0128": "078",
+	"0123": "083", "0124": "084", "0125": "085", "0127": "081", "0129": "082",
+	"0186": "056", "0188": "058", "0199": "059",
+}
+
+// NormaliseVietnamesePhone validates a Vietnamese mobile or landline number
+// written in any of the usual ways ("0912 345 678", "+84 912.345.678",
+// "+84 (0) 912 345 678", "84912345678", "(024) 3826 1234") and returns it in
+// E.164, e.g. +84912345678.
+func NormaliseVietnamesePhone(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	plus := strings.HasPrefix(raw, "+")
+	var sb strings.Builder
+	for i, r := range raw {
+		switch {
+		case r >= '0' && r <= '9':
+			sb.WriteRune(r)
+		case r == ' ' || r == '.' || r == '-' || r == '(' || r == ')' || (r == '+' && i == 0):
+		default:
+			return "", ErrInvalidPhone
+		}
+	}
+	digits := sb.String()
+
+	switch {
+	case plus:
+		if !strings.HasPrefix(digits, "84") {
+			return "", ErrInvalidPhone
+		}
+		digits = "0" + digits[2:]
+	case strings.HasPrefix(digits, "0084"):
+		digits = "0" + digits[4:]
+	case strings.HasPrefix(digits, "84") && (len(digits) == 11 || len(digits) == 12):
+		digits = "0" + digits[2:]
+	case !strings.HasPrefix(digits, "0") && len(digits) == 9:
+		digits = "0" + digits
+	}
+	// "+84 (0) 912 345 678" and "+84 0912345678" repeat the national 0
+	if strings.HasPrefix(digits, "00") {
+		digits = digits[1:]
+	}
+
+	if len(digits) == 11 {
+		if prefix, ok := renumberedMobilePrefixes[digits[:4]]; ok {
+			digits = prefix + digits[4:]
+		}
+	}
+	switch {
+	case len(digits) == 10 && vietnameseMobilePrefixes[digits[:3]]:
+	case len(digits) == 11 && strings.HasPrefix(digits, "02"):
+		// Landlines: a 02x area code and eight digits
+	default:
+		return "", ErrInvalidPhone
+	}
+	return "+84" + digits[1:], nil
+}
+
+// dobLayouts are the ways families write a date of birth on the form. Dates
+// are day first, as is usual in Vietnam.
+var dobLayouts = []string{
+	"2006-01-02",
+	"2/1/2006", "2-1-2006", "2.1.2006",
+	"2/1/06", "2-1-06", "2.1.06",
+}
+
+// ParseCandidateDOB reads a child's date of birth in the usual local formats,
+// such as 01/02/2020, 1/2/20 or 2020-02-01, or an RFC 3339 time sent by a date
+// picker. Dates in the future are rejected.
+func ParseCandidateDOB(raw string) (time.Time, error) {
+	raw = strings.TrimSpace(raw)
+	var dob time.Time
+	parsed := false
+	for _, layout := range dobLayouts {
+		if t, err := time.Parse(layout, raw); err == nil {
+			dob, parsed = t, true
+			break
+		}
+	}
+	if !parsed {
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return time.Time{}, ErrInvalidDOB
+		}
+		// Pickers send local midnight, so the local date is the one meant
+		t = t.In(TourLocation())
+		dob = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	if dob.After(time.Now()) {
+		return time.Time{}, ErrInvalidDOB
+	}
+	return dob, nil
+}
+
+// CandidateAgeGroup returns the kindergarten group of a child born on dob for
+// the school year starting in the year of now. As in Vietnamese schools,
+// groups go by birth year: a child who turns 3 that year joins mầm.
+func CandidateAgeGroup(dob time.Time, now time.Time) string {
+	switch age := now.Year() - dob.Year(); {
+	case age < 1:
+		return models.AgeGroupTooYoung
+	case age <= 2:
+		return models.AgeGroupNursery
+	case age == 3:
+		return models.AgeGroupMam
+	case age == 4:
+		return models.AgeGroupChoi
+	case age == 5:
+		return models.AgeGroupLa
+	}
+	return models.AgeGroupSchoolAge
+}
+
+// maxCandidateAge is the oldest a child on the form can plausibly be; older
+// dates are usually a parent's own date of birth or a mistyped year.
+const maxCandidateAge = 15
+
+const phoneFieldError = "must be a Vietnamese mobile or landline number, e.g. 0912 345 678"
+
+func validateCandidatePhone(raw string, errs FieldErrors) string {
+	if strings.TrimSpace(raw) == "" {
+		errs["phone"] = "is required"
+		return raw
+	}
+	phone, err := NormaliseVietnamesePhone(raw)
+	if err != nil {
+		errs["phone"] = phoneFieldError
+		return raw
+	}
+	return phone
+}
+
+// validateCandidateDOB parses a date of birth. An empty one is allowed and
+// returns a nil date.
+func validateCandidateDOB(raw string, errs FieldErrors) (*time.Time, string) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, ""
+	}
+	dob, err := ParseCandidateDOB(raw)
+	if err != nil {
+		errs["dob"] = "must be a past date such as 25/12/2021 (day/month/year)"
+		return nil, raw
+	}
+	if time.Now().Year()-dob.Year() > maxCandidateAge {
+		errs["dob"] = "is too long ago for a kindergarten child, please check the year"
+		return nil, raw
+	}
+	return &dob, dob.Format("2006-01-02")
+}
+
+// ValidateCandidate checks a submitted candidate and normalises it in place:
+// the phone number to E.164 and the date of birth to YYYY-MM-DD, with
+// BirthDate and AgeGroup filled in. Problems are returned as FieldErrors.
+func ValidateCandidate(candidate *models.Candidate) error {
+	errs := FieldErrors{}
+	candidate.StudentName = strings.TrimSpace(candidate.StudentName)
+	if candidate.StudentName == "" {
+		errs["student_name"] = "is required"
+	}
+	candidate.Phone = validateCandidatePhone(candidate.Phone, errs)
+	candidate.BirthDate, candidate.DateOfBirth = validateCandidateDOB(candidate.DateOfBirth, errs)
+	candidate.AgeGroup = ""
+	if candidate.BirthDate != nil {
+		candidate.AgeGroup = CandidateAgeGroup(*candidate.BirthDate, time.Now())
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ValidateCandidateUpdate does the same for the fields of a partial update,
+// adding birth_date and age_group when the date of birth changes and listing
+// in unset the fields a cleared date of birth removes.
+func ValidateCandidateUpdate(update map[string]interface{}) (unset []string, err error) {
+	errs := FieldErrors{}
+	if value, ok := update["student_name"]; ok {
+		name, _ := value.(string)
+		if name = strings.TrimSpace(name); name == "" {
+			errs["student_name"] = "is required"
+		}
+		update["student_name"] = name
+	}
+	if value, ok := update["phone"]; ok {
+		raw, isString := value.(string)
+		if !isString {
+			errs["phone"] = phoneFieldError
+		} else {
+			update["phone"] = validateCandidatePhone(raw, errs)
+		}
+	}
+	if value, ok := update["dob"]; ok {
+		raw, isString := value.(string)
+		if !isString {
+			errs["dob"] = "must be a date such as 25/12/2021 (day/month/year)"
+		} else {
+			dob, formatted := validateCandidateDOB(raw, errs)
+			update["dob"] = formatted
+			if dob != nil {
+				update["birth_date"] = *dob
+				update["age_group"] = CandidateAgeGroup(*dob, time.Now())
+			} else {
+				unset = []string{"birth_date", "age_group"}
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return unset, nil
+}
+
+// RefreshStoredCandidates brings saved candidates up to date with the rules
+// above: phones in E.164, dates of birth as YYYY-MM-DD with birth_date, and
+// the age group for the current school year, which changes every year. Values
+// that cannot be read are left as they are for staff to correct. It returns
+// how many candidates it changed.
+func RefreshStoredCandidates(db *mongo.Client, now time.Time) (int, error) {
+	collection := db.Database(config.DBName).Collection("Candidate")
+	cursor, err := collection.Find(context.TODO(),
+		bson.M{"status": bson.M{"$ne": models.StatusDeleted}},
+		options.Find().SetProjection(bson.M{"phone": 1, "dob": 1, "birth_date": 1, "age_group": 1}),
+	)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(context.TODO())
+
+	updated := 0
+	for cursor.Next(context.TODO()) {
+		var candidate models.Candidate
+		if err := cursor.Decode(&candidate); err != nil {
+			return updated, err
+		}
+
+		set := bson.M{}
+		if phone, err := NormaliseVietnamesePhone(candidate.Phone); err == nil && phone != candidate.Phone {
+			set["phone"] = phone
+		}
+		birthDate := candidate.BirthDate
+		if birthDate == nil && strings.TrimSpace(candidate.DateOfBirth) != "" {
+			if dob, formatted := validateCandidateDOB(candidate.DateOfBirth, FieldErrors{}); dob != nil {
+				birthDate = dob
+				set["birth_date"] = *dob
+				set["dob"] = formatted
+			}
+		}
+		if birthDate != nil {
+			if group := CandidateAgeGroup(*birthDate, now); group != candidate.AgeGroup {
+				set["age_group"] = group
+			}
+		}
+		if len(set) == 0 {
+			continue
+		}
+		if _, err := collection.UpdateOne(context.TODO(), bson.M{"_id": candidate.ID}, bson.M{"$set": set}); err != nil {
+			return updated, err
+		}
+		updated++
+	}
+	return updated, cursor.Err()
+}
@@ -0,0 +1,223 @@
+package service
+
+import (
+	"amg-backend/config"
+	"amg-backend/models"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/mongo"
+	"math/big"
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CaptchaTTL is how long a challenge can be solved for.
+const CaptchaTTL = 10 * time.Minute
+
+// MaxCaptchaWorkBits caps CAPTCHA.DIFFICULTY for proof-of-work so that a
+// misconfiguration cannot lock phones out of the forms.
+const MaxCaptchaWorkBits = 24
+
+var (
+	ErrCaptchaRequired = errors.New("captcha required")
+	ErrInvalidCaptcha  = errors.New("invalid captcha solution")
+	ErrExpiredCaptcha  = errors.New("captcha expired")
+	ErrUsedCaptcha     = errors.New("captcha already used")
+)
+
+// CaptchaEnabled reports whether public forms must solve a challenge.
+func CaptchaEnabled() bool {
+	return captchaKind() != ""
+}
+
+func captchaKind() string {
+	switch strings.ToLower(strings.TrimSpace(config.CaptchaMode)) {
+	case "off", "none", "disabled":
+		return ""
+	case models.CaptchaKindArithmetic:
+		return models.CaptchaKindArithmetic
+	}
+	return models.CaptchaKindProofOfWork
+}
+
+func captchaDifficulty(kind string) int {
+	difficulty := config.CaptchaDifficulty
+	if kind == models.CaptchaKindProofOfWork {
+		return min(max(difficulty, 1), MaxCaptchaWorkBits)
+	}
+	return max(difficulty, 5)
+}
+
+// captchaSignature signs a challenge payload, proving its id and expiry were
+// issued here.
+func captchaSignature(payload string) []byte {
+	mac := hmac.New(sha256.New, []byte("captcha:"+config.SecretKey))
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+// captchaAnswerSignature signs an arithmetic challenge together with its
+// answer, which never leaves the server, so only a right answer verifies.
+func captchaAnswerSignature(payload string, answer string) []byte {
+	mac := hmac.New(sha256.New, []byte("captcha-answer:"+config.SecretKey))
+	mac.Write([]byte(payload + "|" + answer))
+	return mac.Sum(nil)
+}
+
+func randomInt(n int) (int, error) {
+	v, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, err
+	}
+	return int(v.Int64()), nil
+}
+
+// NewCaptchaChallenge issues a signed challenge of the configured kind.
+func NewCaptchaChallenge() (models.CaptchaChallenge, error) {
+	kind := captchaKind()
+	if kind == "" {
+		kind = models.CaptchaKindProofOfWork
+	}
+	difficulty := captchaDifficulty(kind)
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return models.CaptchaChallenge{}, err
+	}
+	id := hex.EncodeToString(nonce)
+	expiresAt := time.Now().Add(CaptchaTTL)
+	payload := fmt.Sprintf("%s.%s.%d.%d", kind, id, difficulty, expiresAt.Unix())
+
+	challenge := models.CaptchaChallenge{Kind: kind, ExpiresAt: expiresAt}
+	var answerSignature []byte
+	if kind == models.CaptchaKindArithmetic {
+		var numbers [3]int
+		for i := range numbers {
+			n, err := randomInt(difficulty)
+			if err != nil {
+				return models.CaptchaChallenge{}, err
+			}
+			numbers[i] = n
+		}
+		a, b := numbers[0]+1, numbers[1]+1
+		answer := 0
+		if numbers[2]%2 == 0 {
+			challenge.Question = fmt.Sprintf("%d + %d", a, b)
+			answer = a + b
+		} else {
+			a, b = max(a, b), min(a, b)
+			challenge.Question = fmt.Sprintf("%d - %d", a, b)
+			answer = a - b
+		}
+		answerSignature = captchaAnswerSignature(payload, strconv.Itoa(answer))
+	} else {
+		challenge.Challenge = id
+		challenge.Difficulty = difficulty
+	}
+
+	challenge.Token = base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." +
+		base64.RawURLEncoding.EncodeToString(captchaSignature(payload)) + "." +
+		base64.RawURLEncoding.EncodeToString(answerSignature)
+	return challenge, nil
+}
+
+// VerifyCaptcha checks a solution against its challenge token and marks the
+// challenge as used, so each solution is accepted only once.
+func VerifyCaptcha(db *mongo.Client, token string, solution string) error {
+	token, solution = strings.TrimSpace(token), strings.TrimSpace(solution)
+	if token == "" || solution == "" {
+		return ErrCaptchaRequired
+	}
+
+	encoded := strings.Split(token, ".")
+	if len(encoded) != 3 {
+		return ErrInvalidCaptcha
+	}
+	payloadBytes, err := base64.RawURLEncoding.DecodeString(encoded[0])
+	if err != nil {
+		return ErrInvalidCaptcha
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encoded[1])
+	if err != nil {
+		return ErrInvalidCaptcha
+	}
+	answerSignature, err := base64.RawURLEncoding.DecodeString(encoded[2])
+	if err != nil {
+		return ErrInvalidCaptcha
+	}
+
+	payload := string(payloadBytes)
+	// Checked before anything is recorded, so forged ids cannot fill the
+	// used challenges collection
+	if !hmac.Equal(signature, captchaSignature(payload)) {
+		return ErrInvalidCaptcha
+	}
+	parts := strings.Split(payload, ".")
+	if len(parts) != 4 {
+		return ErrInvalidCaptcha
+	}
+	kind, id := parts[0], parts[1]
+	difficulty, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return ErrInvalidCaptcha
+	}
+	expiresAt, err := strconv.ParseInt(parts[3], 10, 64)
+	if err != nil {
+		return ErrInvalidCaptcha
+	}
+
+	if time.Now().Unix() > expiresAt {
+		return ErrExpiredCaptcha
+	}
+
+	switch kind {
+	case models.CaptchaKindArithmetic:
+		// The answer space is small, so every attempt uses the challenge up
+		// and a wrong answer means fetching a new one
+		if err := useCaptcha(db, id); err != nil {
+			return err
+		}
+		answer, err := strconv.Atoi(solution)
+		if err != nil || !hmac.Equal(answerSignature, captchaAnswerSignature(payload, strconv.Itoa(answer))) {
+			return ErrInvalidCaptcha
+		}
+		return nil
+	case models.CaptchaKindProofOfWork:
+		if leadingZeroBits(sha256.Sum256([]byte(id+":"+solution))) < difficulty {
+			return ErrInvalidCaptcha
+		}
+		return useCaptcha(db, id)
+	}
+	return ErrInvalidCaptcha
+}
+
+// useCaptcha records a challenge as used, failing if it already was.
+func useCaptcha(db *mongo.Client, id string) error {
+	solved := models.CaptchaSolution{ID: id, UsedAt: time.Now()}
+	if _, err := db.Database(config.DBName).Collection("CaptchaSolution").InsertOne(context.TODO(), solved); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrUsedCaptcha
+		}
+		return err
+	}
+	return nil
+}
+
+func leadingZeroBits(sum [sha256.Size]byte) int {
+	count := 0
+	for _, b := range sum {
+		if b != 0 {
+			return count + bits.LeadingZeros8(b)
+		}
+		count += 8
+	}
+	return count
+}
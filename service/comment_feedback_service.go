@@ -0,0 +1,210 @@
+package service
+
+import (
+	"amg-backend/config"
+	"amg-backend/models"
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+// MaxReportReasonLength caps the reason readers give when reporting a comment.
+const MaxReportReasonLength = 500
+
+var (
+	ErrInvalidReaction     = errors.New("unknown reaction type")
+	ErrAlreadyReported     = errors.New("comment already reported")
+	ErrCommentNotPublished = errors.New("comment is not published")
+)
+
+// findPublishedComment loads a comment readers can see, the only kind that can
+// be reacted to or reported.
+func findPublishedComment(db *mongo.Client, id primitive.ObjectID) (models.Comment, error) {
+	comment, err := FindComment(db, id)
+	if err != nil {
+		return comment, err
+	}
+	if !models.IsPublishedCommentStatus(comment.Status) {
+		return comment, ErrCommentNotPublished
+	}
+	return comment, nil
+}
+
+// ReactToComment sets the user's reaction to a comment, replacing any
+// reaction they gave before.
+func ReactToComment(db *mongo.Client, commentID primitive.ObjectID, userID string, kind string) error {
+	if !models.IsValidReaction(kind) {
+		return ErrInvalidReaction
+	}
+	if _, err := findPublishedComment(db, commentID); err != nil {
+		return err
+	}
+
+	_, err := db.Database(config.DBName).Collection("CommentReaction").UpdateOne(context.TODO(),
+		bson.M{"comment_id": commentID, "user_id": userID},
+		bson.M{
+			"$set":         bson.M{"type": kind, "created_at": time.Now()},
+			"$setOnInsert": bson.M{"_id": primitive.NewObjectID()},
+		},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+// RemoveCommentReaction withdraws the user's reaction. It reports whether
+// there was one.
+func RemoveCommentReaction(db *mongo.Client, commentID primitive.ObjectID, userID string) (bool, error) {
+	result, err := db.Database(config.DBName).Collection("CommentReaction").DeleteOne(context.TODO(),
+		bson.M{"comment_id": commentID, "user_id": userID},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.DeletedCount > 0, nil
+}
+
+// AttachCommentReactions fills in the reaction counts of the views, and the
+// reaction of userID when it is not empty.
+func AttachCommentReactions(db *mongo.Client, views []*models.CommentView, userID string) error {
+	byID := make(map[primitive.ObjectID]*models.CommentView)
+	var collect func([]*models.CommentView)
+	collect = func(views []*models.CommentView) {
+		for _, view := range views {
+			if !view.Deleted {
+				byID[view.ID] = view
+			}
+			collect(view.Replies)
+		}
+	}
+	collect(views)
+	if len(byID) == 0 {
+		return nil
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(byID))
+	for id := range byID {
+		ids = append(ids, id)
+	}
+	collection := db.Database(config.DBName).Collection("CommentReaction")
+	cursor, err := collection.Aggregate(context.TODO(), mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"comment_id": bson.M{"$in": ids}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   bson.M{"comment_id": "$comment_id", "type": "$type"},
+			"count": bson.M{"$sum": 1},
+		}}},
+	})
+	if err != nil {
+		return err
+	}
+	var counts []struct {
+		ID struct {
+			CommentID primitive.ObjectID `bson:"comment_id"`
+			Type      string             `bson:"type"`
+		} `bson:"_id"`
+		Count int `bson:"count"`
+	}
+	if err := cursor.All(context.TODO(), &counts); err != nil {
+		return err
+	}
+	for _, count := range counts {
+		view := byID[count.ID.CommentID]
+		if view.Reactions == nil {
+			view.Reactions = make(map[string]int)
+		}
+		view.Reactions[count.ID.Type] = count.Count
+	}
+
+	if userID == "" {
+		return nil
+	}
+	cursor, err = collection.Find(context.TODO(), bson.M{"comment_id": bson.M{"$in": ids}, "user_id": userID})
+	if err != nil {
+		return err
+	}
+	var mine []models.CommentReaction
+	if err := cursor.All(context.TODO(), &mine); err != nil {
+		return err
+	}
+	for _, reaction := range mine {
+		byID[reaction.CommentID].MyReaction = reaction.Type
+	}
+	return nil
+}
+
+// ReportComment records a reader's report. Once config.CommentReportThreshold
+// signed-in readers have reported a published comment it goes back to pending
+// until staff review it; anonymous reports are kept for staff but cannot hide
+// a comment, as a visitor can pose as many. It reports whether this report hid
+// the comment.
+func ReportComment(db *mongo.Client, commentID primitive.ObjectID, reporter string, member bool, reason string) (bool, error) {
+	if _, err := findPublishedComment(db, commentID); err != nil {
+		return false, err
+	}
+
+	database := db.Database(config.DBName)
+	report := models.CommentReport{
+		ID:        primitive.NewObjectID(),
+		CommentID: commentID,
+		Reporter:  reporter,
+		Reason:    reason,
+		CreatedAt: time.Now(),
+	}
+	if _, err := database.Collection("CommentReport").InsertOne(context.TODO(), report); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return false, ErrAlreadyReported
+		}
+		return false, err
+	}
+
+	collection := database.Collection("Comment")
+	inc := bson.M{"report_count": 1}
+	if member {
+		inc["member_report_count"] = 1
+	}
+	var comment models.Comment
+	err := collection.FindOneAndUpdate(context.TODO(),
+		bson.M{"_id": commentID},
+		bson.M{"$inc": inc},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&comment)
+	if err != nil {
+		return false, err
+	}
+
+	threshold := config.CommentReportThreshold
+	if !member || threshold <= 0 || comment.MemberReportCount < threshold {
+		return false, nil
+	}
+	result, err := collection.UpdateOne(context.TODO(),
+		bson.M{"_id": commentID, "status": bson.M{"$in": bson.A{models.CommentStatusNew, models.CommentStatusApproved}}},
+		bson.M{"$set": bson.M{"status": models.CommentStatusPending, "updated_at": time.Now()}},
+	)
+	if err != nil {
+		return false, err
+	}
+	if result.ModifiedCount == 0 {
+		return false, nil
+	}
+	refreshCommentStatsOf(db, comment.PostId)
+	return true, nil
+}
+
+// CommentReports lists the reports on a comment, oldest first.
+func CommentReports(db *mongo.Client, commentID primitive.ObjectID) ([]models.CommentReport, error) {
+	cursor, err := db.Database(config.DBName).Collection("CommentReport").Find(context.TODO(),
+		bson.M{"comment_id": commentID},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	reports := make([]models.CommentReport, 0)
+	if err := cursor.All(context.TODO(), &reports); err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
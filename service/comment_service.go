@@ -0,0 +1,315 @@
+package service
+
+import (
+	"amg-backend/config"
+	"amg-backend/models"
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+var ErrInvalidParentComment = errors.New("parent comment not found in this post")
+
+// ReplyPlacement resolves where a reply to parentId goes. Replies deeper than
+// config.CommentMaxDepth are attached next to the comment they answer, so the
+// conversation stays readable on small screens.
+func ReplyPlacement(db *mongo.Client, postId string, parentId string) (string, int, error) {
+	if parentId == "" {
+		return "", 0, nil
+	}
+	id, err := primitive.ObjectIDFromHex(parentId)
+	if err != nil {
+		return "", 0, ErrInvalidParentComment
+	}
+
+	var parent models.Comment
+	// Only published comments can be answered
+	err = db.Database(config.DBName).Collection("Comment").FindOne(context.TODO(), bson.M{
+		"_id":     id,
+		"post_id": postId,
+		"status":  bson.M{"$in": bson.A{models.CommentStatusNew, models.CommentStatusApproved}},
+	}).Decode(&parent)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return "", 0, ErrInvalidParentComment
+	}
+	if err != nil {
+		return "", 0, err
+	}
+
+	maxDepth := config.CommentMaxDepth
+	if maxDepth < 1 {
+		maxDepth = 1
+	}
+	if parent.Depth+1 >= maxDepth {
+		return parent.ParentId, parent.Depth, nil
+	}
+	return parent.ID.Hex(), parent.Depth + 1, nil
+}
+
+// BuildCommentThreads arranges the comments of a post into threads: top-level
+// comments newest first, replies oldest first below the comment they answer.
+// comments must include deleted ones; those, and unpublished ones unless
+// showUnpublished is set (for staff), are kept as placeholders while they have
+// visible replies and dropped otherwise. Replies whose parent is gone for good
+// are shown as top-level comments.
+func BuildCommentThreads(comments []models.Comment, showUnpublished bool) []*models.CommentView {
+	views := make(map[string]*models.CommentView, len(comments))
+	for _, comment := range comments {
+		hidden := comment.Status == models.StatusDeleted ||
+			(!showUnpublished && !models.IsPublishedCommentStatus(comment.Status))
+		view := &models.CommentView{
+			Comment: comment,
+			IsStaff: models.IsStaffRole(comment.AuthorRole),
+			Edited:  comment.EditedAt != nil,
+			Deleted: hidden,
+		}
+		if !showUnpublished {
+			// Filter scores and reports are for moderators only
+			view.SpamScore = 0
+			view.SpamReasons = nil
+			view.ReportCount = 0
+			view.MemberReportCount = 0
+		}
+		if view.Deleted {
+			view.Content = ""
+			view.AuthorName = ""
+			view.AuthorId = ""
+			view.IsStaff = false
+			view.Edited = false
+			view.EditedAt = nil
+		}
+		views[comment.ID.Hex()] = view
+	}
+
+	roots := make([]*models.CommentView, 0)
+	for _, comment := range comments {
+		view := views[comment.ID.Hex()]
+		if parent, ok := views[comment.ParentId]; ok && comment.ParentId != "" {
+			parent.Replies = append(parent.Replies, view)
+		} else {
+			roots = append(roots, view)
+		}
+	}
+
+	sort.SliceStable(roots, func(i, j int) bool { return roots[i].CreatedAt.After(roots[j].CreatedAt) })
+	return pruneCommentThreads(roots, 0)
+}
+
+// pruneCommentThreads drops deleted comments without visible replies, sorts
+// replies and recomputes depths.
+func pruneCommentThreads(views []*models.CommentView, depth int) []*models.CommentView {
+	kept := make([]*models.CommentView, 0, len(views))
+	for _, view := range views {
+		sort.SliceStable(view.Replies, func(i, j int) bool { return view.Replies[i].CreatedAt.Before(view.Replies[j].CreatedAt) })
+		view.Replies = pruneCommentThreads(view.Replies, depth+1)
+		if view.Deleted && len(view.Replies) == 0 {
+			continue
+		}
+		view.Depth = depth
+		kept = append(kept, view)
+	}
+	return kept
+}
+
+// FlattenCommentThreads lists threaded comments in reading order, each with
+// its depth, for clients that render indentation themselves.
+func FlattenCommentThreads(threads []*models.CommentView) []*models.CommentView {
+	flat := make([]*models.CommentView, 0)
+	var walk func([]*models.CommentView)
+	walk = func(views []*models.CommentView) {
+		for _, view := range views {
+			replies := view.Replies
+			view.Replies = nil
+			flat = append(flat, view)
+			walk(replies)
+		}
+	}
+	walk(threads)
+	return flat
+}
+
+var (
+	ErrCommentNotFound       = errors.New("comment not found")
+	ErrCommentForbidden      = errors.New("not allowed to change this comment")
+	ErrCommentEditWindowOver = errors.New("comment can no longer be changed")
+)
+
+// CommentEditWindow is how long authors can edit or delete their own comments.
+func CommentEditWindow() time.Duration {
+	minutes := config.CommentEditWindowMinutes
+	if minutes <= 0 {
+		minutes = 15
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// CheckCommentOwnership returns nil if the user may edit or delete comment:
+// staff always may, authors only within CommentEditWindow. Anonymous
+// comments have no author to check against, so only staff can change them.
+func CheckCommentOwnership(comment models.Comment, userID string, role string) error {
+	if models.IsStaffRole(role) {
+		return nil
+	}
+	if userID == "" || comment.AuthorId != userID {
+		return ErrCommentForbidden
+	}
+	if time.Since(comment.CreatedAt) > CommentEditWindow() {
+		return ErrCommentEditWindowOver
+	}
+	return nil
+}
+
+// Shown instead of the name of a signed-in commenter whose account has none;
+// usernames are login handles such as phone numbers and are never published.
+const (
+	StaffCommentAuthor  = "Nhà trường"
+	MemberCommentAuthor = "Phụ huynh"
+)
+
+// CommentAuthorName returns the public name of a signed-in commenter: the
+// name on their account, or a neutral label when it is empty.
+func CommentAuthorName(db *mongo.Client, userID string, role string) string {
+	fallback := MemberCommentAuthor
+	if models.IsStaffRole(role) {
+		fallback = StaffCommentAuthor
+	}
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return fallback
+	}
+	var user models.User
+	err = db.Database(config.DBName).Collection("User").FindOne(context.TODO(),
+		bson.M{"_id": id},
+		options.FindOne().SetProjection(bson.M{"name": 1}),
+	).Decode(&user)
+	if err != nil {
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			log.Printf("Warning: could not load the name of user %s: %v", userID, err)
+		}
+		return fallback
+	}
+	if name := strings.TrimSpace(user.Name); name != "" {
+		return name
+	}
+	return fallback
+}
+
+// ScreenComment runs the content filters on a comment by someone other than
+// staff and flags it as spam if needed.
+func ScreenComment(comment *models.Comment, honeypot string) {
+	if models.IsStaffRole(comment.AuthorRole) {
+		return
+	}
+	verdict := CheckSubmission(Submission{
+		Fields:   []string{comment.AuthorName, comment.Content},
+		Honeypot: honeypot,
+	})
+	comment.SpamScore = verdict.Score
+	comment.SpamReasons = nil
+	if verdict.Score > 0 {
+		comment.SpamReasons = verdict.Reasons
+	}
+	if verdict.IsSpam {
+		comment.Status = models.CommentStatusSpam
+	}
+}
+
+// FindComment loads a comment that is not in the trash.
+func FindComment(db *mongo.Client, id primitive.ObjectID) (models.Comment, error) {
+	var comment models.Comment
+	err := db.Database(config.DBName).Collection("Comment").FindOne(context.TODO(),
+		bson.M{"_id": id, "status": bson.M{"$ne": models.StatusDeleted}},
+	).Decode(&comment)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return comment, ErrCommentNotFound
+	}
+	return comment, err
+}
+
+// EditComment replaces the content of a comment on behalf of userID, keeping
+// the previous content as a CommentRevision. Edits by anyone but staff go
+// through the content filters again, and a published comment goes back to
+// the moderation queue if a new comment by the author would have to.
+func EditComment(db *mongo.Client, id primitive.ObjectID, content string, userID string, role string) (models.Comment, error) {
+	comment, err := FindComment(db, id)
+	if err != nil {
+		return comment, err
+	}
+	if err := CheckCommentOwnership(comment, userID, role); err != nil {
+		return comment, err
+	}
+	if content == comment.Content {
+		return comment, nil
+	}
+
+	now := time.Now()
+	database := db.Database(config.DBName)
+	revision := models.CommentRevision{
+		ID:        primitive.NewObjectID(),
+		CommentID: comment.ID,
+		Content:   comment.Content,
+		EditedBy:  userID,
+		CreatedAt: now,
+	}
+
+	previous := comment.Status
+	comment.Content = content
+	comment.EditedAt = &now
+	comment.UpdatedAt = now
+	if !models.IsStaffRole(role) {
+		ScreenComment(&comment, "")
+		if comment.Status != models.CommentStatusSpam && models.IsPublishedCommentStatus(previous) {
+			postID, _ := primitive.ObjectIDFromHex(comment.PostId)
+			status, err := InitialCommentStatus(db, postID, comment.AuthorId, comment.AuthorRole)
+			if err != nil {
+				return comment, err
+			}
+			if status == models.CommentStatusPending {
+				comment.Status = status
+			}
+		}
+	}
+
+	_, err = database.Collection("Comment").UpdateByID(context.TODO(), id, bson.M{"$set": bson.M{
+		"content":      comment.Content,
+		"status":       comment.Status,
+		"spam_score":   comment.SpamScore,
+		"spam_reasons": comment.SpamReasons,
+		"edited_at":    now,
+		"updated_at":   now,
+	}})
+	if err != nil {
+		return comment, err
+	}
+	// The revision is only kept once the edit it records has been saved
+	if _, err := database.Collection("CommentRevision").InsertOne(context.TODO(), revision); err != nil {
+		log.Printf("Warning: could not save the previous version of comment %s: %v", id.Hex(), err)
+	}
+	if comment.Status != previous {
+		refreshCommentStatsOf(db, comment.PostId)
+	}
+	return comment, nil
+}
+
+// CommentHistory returns the earlier versions of a comment, oldest first.
+func CommentHistory(db *mongo.Client, id primitive.ObjectID) ([]models.CommentRevision, error) {
+	cursor, err := db.Database(config.DBName).Collection("CommentRevision").Find(context.TODO(),
+		bson.M{"comment_id": id},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	revisions := make([]models.CommentRevision, 0)
+	if err := cursor.All(context.TODO(), &revisions); err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"amg-backend/config"
+	"amg-backend/models"
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"log"
+	"time"
+)
+
+// publishedCommentFilter matches the comments readers see.
+func publishedCommentFilter() bson.M {
+	return bson.M{"status": bson.M{"$in": bson.A{models.CommentStatusNew, models.CommentStatusApproved}}}
+}
+
+// RefreshPostCommentStats recounts the published comments of a post and
+// stores the count and the time of the newest one on the post, so that
+// listings can show them without touching the Comment collection.
+func RefreshPostCommentStats(db *mongo.Client, postId string) error {
+	id, err := primitive.ObjectIDFromHex(postId)
+	if err != nil {
+		return nil
+	}
+
+	database := db.Database(config.DBName)
+	filter := publishedCommentFilter()
+	filter["post_id"] = postId
+	cursor, err := database.Collection("Comment").Aggregate(context.TODO(), mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   nil,
+			"count": bson.M{"$sum": 1},
+			"last":  bson.M{"$max": "$created_at"},
+		}}},
+	})
+	if err != nil {
+		return err
+	}
+	var stats []struct {
+		Count int       `bson:"count"`
+		Last  time.Time `bson:"last"`
+	}
+	if err := cursor.All(context.TODO(), &stats); err != nil {
+		return err
+	}
+
+	update := bson.M{"$set": bson.M{"comment_count": 0}, "$unset": bson.M{"last_comment_at": ""}}
+	if len(stats) > 0 {
+		update = bson.M{"$set": bson.M{"comment_count": stats[0].Count, "last_comment_at": stats[0].Last}}
+	}
+	_, err = database.Collection("Post").UpdateByID(context.TODO(), id, update)
+	return err
+}
+
+// refreshCommentStatsOf refreshes the stats of the post a comment belongs to,
+// logging failures: the nightly job corrects any that slip through.
+func refreshCommentStatsOf(db *mongo.Client, postId string) {
+	if err := RefreshPostCommentStats(db, postId); err != nil {
+		log.Printf("Warning: could not refresh comment stats of post %s: %v", postId, err)
+	}
+}
+
+func refreshCommentStatsOfComment(db *mongo.Client, commentID primitive.ObjectID) {
+	var comment models.Comment
+	err := db.Database(config.DBName).Collection("Comment").FindOne(context.TODO(), bson.M{"_id": commentID}).Decode(&comment)
+	if err != nil {
+		log.Printf("Warning: could not refresh comment stats for comment %s: %v", commentID.Hex(), err)
+		return
+	}
+	refreshCommentStatsOf(db, comment.PostId)
+}
+
+// RefreshAllPostCommentStats recomputes the comment stats of every post. It
+// returns the number of posts that have published comments.
+func RefreshAllPostCommentStats(db *mongo.Client) (int, error) {
+	database := db.Database(config.DBName)
+	cursor, err := database.Collection("Comment").Aggregate(context.TODO(), mongo.Pipeline{
+		{{Key: "$match", Value: publishedCommentFilter()}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   "$post_id",
+			"count": bson.M{"$sum": 1},
+			"last":  bson.M{"$max": "$created_at"},
+		}}},
+	})
+	if err != nil {
+		return 0, err
+	}
+	var stats []struct {
+		PostId string    `bson:"_id"`
+		Count  int       `bson:"count"`
+		Last   time.Time `bson:"last"`
+	}
+	if err := cursor.All(context.TODO(), &stats); err != nil {
+		return 0, err
+	}
+
+	posts := database.Collection("Post")
+	withComments := make([]primitive.ObjectID, 0, len(stats))
+	for _, stat := range stats {
+		id, err := primitive.ObjectIDFromHex(stat.PostId)
+		if err != nil {
+			continue
+		}
+		withComments = append(withComments, id)
+		_, err = posts.UpdateByID(context.TODO(), id, bson.M{"$set": bson.M{"comment_count": stat.Count, "last_comment_at": stat.Last}})
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	_, err = posts.UpdateMany(context.TODO(),
+		bson.M{"_id": bson.M{"$nin": withComments}, "comment_count": bson.M{"$ne": 0}},
+		bson.M{"$set": bson.M{"comment_count": 0}, "$unset": bson.M{"last_comment_at": ""}},
+	)
+	return len(withComments), err
+}
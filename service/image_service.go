@@ -9,27 +9,41 @@ import (
 	"regexp"
 )
 
-func MarkImagesAsUsed(db *mongo.Client, content string) error {
-	re := regexp.MustCompile(regexp.QuoteMeta(config.BaseURL) + `/uploads/([a-f0-9\-]+\.[a-zA-Z]+)`)
-	matches := re.FindAllStringSubmatch(content, -1)
+// uploadURLRegex finds /uploads URLs, relative or with a scheme and host in
+// front; the host is checked against config.BaseURL, which is only known once
+// the config is loaded.
+var uploadURLRegex = regexp.MustCompile(`(?:^|["'(<=\s])(https?://[^"'()\s<>]*?)?(/uploads/[^"'()\s<>?#]+)`)
 
-	if len(matches) == 0 {
-		return nil // Không có ảnh nào để xử lý
-	}
+// ExtractImageUrls returns the distinct /uploads URLs referenced in content.
+// Both relative ("/uploads/x.png") and absolute ("<BASE_URL>/uploads/x.png")
+// forms are recognised and normalised to the relative form stored in
+// UploadedImage.URL. This works on HTML as well as Markdown sources, where
+// images appear as ![alt](url), <url> or reference definitions.
+func ExtractImageUrls(content string) []string {
+	matches := uploadURLRegex.FindAllStringSubmatch(content, -1)
 
-	var filenames []string
+	seen := make(map[string]bool)
+	urls := make([]string, 0, len(matches))
 	for _, match := range matches {
-		if len(match) > 1 {
-			filenames = append(filenames, match[1])
+		if match[1] != "" && match[1] != config.BaseURL {
+			continue
+		}
+		if !seen[match[2]] {
+			seen[match[2]] = true
+			urls = append(urls, match[2])
 		}
 	}
+	return urls
+}
 
-	if len(filenames) == 0 {
-		return nil
+func MarkImagesAsUsed(db *mongo.Client, content string) error {
+	urls := ExtractImageUrls(content)
+	if len(urls) == 0 {
+		return nil // Không có ảnh nào để xử lý
 	}
 
 	collection := db.Database(config.DBName).Collection("UploadedImage")
-	filter := bson.M{"filename": bson.M{"$in": filenames}}
+	filter := bson.M{"url": bson.M{"$in": urls}}
 	update := bson.M{"$set": bson.M{"status": models.ImageStatusUsed}}
 
 	_, err := collection.UpdateMany(context.TODO(), filter, update)
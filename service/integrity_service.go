@@ -0,0 +1,319 @@
+package service
+
+import (
+	"amg-backend/config"
+	"amg-backend/models"
+	"context"
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// IntegrityReportRetention is how long old integrity reports are kept.
+const IntegrityReportRetention = 30 * 24 * time.Hour
+
+var (
+	hrefRegex     = regexp.MustCompile(`(?i)href\s*=\s*["']([^"']+)["']`)
+	objectIDRegex = regexp.MustCompile(`(?:^|[/=])([0-9a-f]{24})(?:$|[/?#&])`)
+)
+
+// integrityRef is one reference found while scanning, before it is checked.
+type integrityRef struct {
+	source      string
+	sourceID    string
+	sourceTitle string
+	field       string
+	url         string
+	// Header images are saved without an UploadedImage record
+	needsRecord bool
+}
+
+// RunIntegrityCheck scans post contents, header images and the landing page
+// for /uploads references whose file or UploadedImage record is gone (or about
+// to be cleaned up), and for internal links to deleted posts. The report is
+// stored and returned.
+func RunIntegrityCheck(db *mongo.Client) (*models.IntegrityReport, error) {
+	database := db.Database(config.DBName)
+	report := &models.IntegrityReport{
+		CheckedAt: time.Now(),
+		Counts:    make(map[string]int),
+		Issues:    []models.IntegrityIssue{},
+	}
+
+	var imageRefs []integrityRef
+	var linkRefs []integrityRef
+
+	// Posts in the trash are not shown anywhere, so they are not scanned
+	cursor, err := database.Collection("Post").Find(context.TODO(),
+		bson.M{"status": bson.M{"$ne": models.PostStatusDeleted}},
+		options.Find().SetProjection(bson.M{"title": 1, "content": 1, "header_image": 1}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	var posts []models.Post
+	if err := cursor.All(context.TODO(), &posts); err != nil {
+		return nil, err
+	}
+	report.PostsScanned = len(posts)
+
+	for _, post := range posts {
+		base := integrityRef{source: models.IntegritySourcePost, sourceID: post.ID.Hex(), sourceTitle: post.Title}
+		for _, u := range ExtractImageUrls(post.Content) {
+			ref := base
+			ref.field, ref.url, ref.needsRecord = "content", u, true
+			imageRefs = append(imageRefs, ref)
+		}
+		if urls := ExtractImageUrls(post.HeaderImage); len(urls) > 0 {
+			ref := base
+			ref.field, ref.url = "header_image", urls[0]
+			imageRefs = append(imageRefs, ref)
+		}
+		for _, link := range internalPostLinks(post.Content) {
+			ref := base
+			ref.field, ref.url = "content", link
+			linkRefs = append(linkRefs, ref)
+		}
+	}
+
+	var landingPages []models.LandingPageContent
+	cursor, err = database.Collection("LandingPageContent").Find(context.TODO(), bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(context.TODO(), &landingPages); err != nil {
+		return nil, err
+	}
+	for _, page := range landingPages {
+		walkJSONStrings("content", page.Content, func(path string, value string) {
+			base := integrityRef{source: models.IntegritySourceLandingPage, sourceID: page.Key, field: path}
+			for _, u := range ExtractImageUrls(value) {
+				ref := base
+				ref.url, ref.needsRecord = u, true
+				imageRefs = append(imageRefs, ref)
+			}
+			for _, link := range internalPostLinks(value) {
+				ref := base
+				ref.url = link
+				linkRefs = append(linkRefs, ref)
+			}
+		})
+	}
+	report.ReferencesChecked = len(imageRefs) + len(linkRefs)
+
+	if err := checkImageRefs(db, imageRefs, report); err != nil {
+		return nil, err
+	}
+	if err := checkPostLinks(db, linkRefs, report); err != nil {
+		return nil, err
+	}
+
+	collection := database.Collection("IntegrityReport")
+	if _, err := collection.InsertOne(context.TODO(), report); err != nil {
+		return nil, err
+	}
+	if _, err := collection.DeleteMany(context.TODO(), bson.M{"checked_at": bson.M{"$lt": time.Now().Add(-IntegrityReportRetention)}}); err != nil {
+		log.Printf("Warning: could not prune old integrity reports: %v", err)
+	}
+	return report, nil
+}
+
+// GetLatestIntegrityReport returns the most recent stored report, or nil when
+// the check has never run.
+func GetLatestIntegrityReport(db *mongo.Client) (*models.IntegrityReport, error) {
+	var report models.IntegrityReport
+	err := db.Database(config.DBName).Collection("IntegrityReport").FindOne(context.TODO(), bson.M{},
+		options.FindOne().SetSort(bson.M{"checked_at": -1}),
+	).Decode(&report)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+func checkImageRefs(db *mongo.Client, refs []integrityRef, report *models.IntegrityReport) error {
+	if len(refs) == 0 {
+		return nil
+	}
+
+	urlSet := make(map[string]bool)
+	for _, ref := range refs {
+		urlSet[ref.url] = true
+	}
+	urls := make([]string, 0, len(urlSet))
+	for u := range urlSet {
+		urls = append(urls, u)
+	}
+
+	cursor, err := db.Database(config.DBName).Collection("UploadedImage").Find(context.TODO(),
+		bson.M{"url": bson.M{"$in": urls}},
+		options.Find().SetProjection(bson.M{"url": 1, "status": 1}),
+	)
+	if err != nil {
+		return err
+	}
+	var images []models.UploadedImage
+	if err := cursor.All(context.TODO(), &images); err != nil {
+		return err
+	}
+	records := make(map[string]models.ImageStatus, len(images))
+	for _, image := range images {
+		records[image.URL] = image.Status
+	}
+
+	fileExists := make(map[string]bool, len(urls))
+	for _, u := range urls {
+		fileExists[u] = uploadFileExists(u)
+	}
+
+	for _, ref := range refs {
+		status, recorded := records[ref.url]
+		switch {
+		case !fileExists[ref.url]:
+			addIntegrityIssue(report, models.IntegrityMissingFile, ref)
+		case ref.needsRecord && !recorded:
+			addIntegrityIssue(report, models.IntegrityMissingRecord, ref)
+		case recorded && status == models.ImageStatusPending:
+			addIntegrityIssue(report, models.IntegrityPendingRecord, ref)
+		}
+	}
+	return nil
+}
+
+func checkPostLinks(db *mongo.Client, refs []integrityRef, report *models.IntegrityReport) error {
+	if len(refs) == 0 {
+		return nil
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(refs))
+	for _, ref := range refs {
+		if id, err := primitive.ObjectIDFromHex(linkedPostID(ref.url)); err == nil {
+			ids = append(ids, id)
+		}
+	}
+
+	cursor, err := db.Database(config.DBName).Collection("Post").Find(context.TODO(),
+		bson.M{"_id": bson.M{"$in": ids}},
+		options.Find().SetProjection(bson.M{"status": 1}),
+	)
+	if err != nil {
+		return err
+	}
+	var posts []models.Post
+	if err := cursor.All(context.TODO(), &posts); err != nil {
+		return err
+	}
+	statuses := make(map[string]string, len(posts))
+	for _, post := range posts {
+		statuses[post.ID.Hex()] = post.Status
+	}
+
+	for _, ref := range refs {
+		status, exists := statuses[linkedPostID(ref.url)]
+		if !exists {
+			addIntegrityIssue(report, models.IntegrityMissingPostLink, ref)
+		} else if status == models.PostStatusDeleted {
+			addIntegrityIssue(report, models.IntegrityDeletedPostLink, ref)
+		}
+	}
+	return nil
+}
+
+func addIntegrityIssue(report *models.IntegrityReport, issueType string, ref integrityRef) {
+	report.Counts[issueType]++
+	report.Issues = append(report.Issues, models.IntegrityIssue{
+		Type:        issueType,
+		Source:      ref.source,
+		SourceID:    ref.sourceID,
+		SourceTitle: ref.sourceTitle,
+		Field:       ref.field,
+		URL:         ref.url,
+	})
+}
+
+// uploadFileExists reports whether the file behind an /uploads URL is on disk.
+func uploadFileExists(u string) bool {
+	path, err := url.PathUnescape(u)
+	if err != nil {
+		return false
+	}
+	path = filepath.Clean("." + path)
+	if !strings.HasPrefix(path, "uploads"+string(filepath.Separator)) {
+		return false
+	}
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// internalPostLinks returns the links in content that point at a post on this
+// site: relative or BaseURL links whose path carries a post ID.
+func internalPostLinks(content string) []string {
+	var links []string
+	for _, match := range hrefRegex.FindAllStringSubmatch(content, -1) {
+		link := match[1]
+		if config.BaseURL != "" && strings.HasPrefix(link, config.BaseURL) {
+			link = strings.TrimPrefix(link, config.BaseURL)
+		}
+		if !strings.HasPrefix(link, "/") || strings.HasPrefix(link, "//") {
+			continue
+		}
+		if strings.HasPrefix(link, "/uploads/") || strings.HasPrefix(link, "/attachments/") {
+			continue
+		}
+		if linkedPostID(link) != "" {
+			links = append(links, match[1])
+		}
+	}
+	return links
+}
+
+func linkedPostID(link string) string {
+	if match := objectIDRegex.FindStringSubmatch(link); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+// walkJSONStrings calls fn for every string in a decoded JSON document, with
+// a dotted path such as "content.hero.images.0".
+func walkJSONStrings(path string, value interface{}, fn func(path string, value string)) {
+	switch v := value.(type) {
+	case string:
+		fn(path, v)
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			walkJSONStrings(path+"."+key, v[key], fn)
+		}
+	case bson.M:
+		walkJSONStrings(path, map[string]interface{}(v), fn)
+	case []interface{}:
+		for i, item := range v {
+			walkJSONStrings(fmt.Sprintf("%s.%d", path, i), item, fn)
+		}
+	case bson.A:
+		walkJSONStrings(path, []interface{}(v), fn)
+	case bson.D:
+		for _, element := range v {
+			walkJSONStrings(path+"."+element.Key, element.Value, fn)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package service
+
+import (
+	"amg-backend/models"
+	"bytes"
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/renderer/html"
+	"strings"
+)
+
+var (
+	// Raw HTML is let through goldmark and then cleaned by the sanitizer, so
+	// editors can still embed simple markup inside Markdown.
+	markdownRenderer = goldmark.New(
+		goldmark.WithExtensions(extension.GFM),
+		goldmark.WithRendererOptions(html.WithUnsafe()),
+	)
+	markdownPolicy = bluemonday.UGCPolicy()
+)
+
+// NormalizeContentFormat maps an incoming content_format value to one of the
+// supported formats. ok is false for unknown values; empty means HTML.
+func NormalizeContentFormat(format string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "", models.ContentFormatHTML:
+		return models.ContentFormatHTML, true
+	case models.ContentFormatMarkdown, "md":
+		return models.ContentFormatMarkdown, true
+	}
+	return "", false
+}
+
+// RenderMarkdown converts Markdown source into sanitised HTML.
+func RenderMarkdown(source string) (string, error) {
+	var buf bytes.Buffer
+	if err := markdownRenderer.Convert([]byte(source), &buf); err != nil {
+		return "", err
+	}
+	return markdownPolicy.Sanitize(buf.String()), nil
+}
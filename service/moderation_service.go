@@ -0,0 +1,207 @@
+package service
+
+import (
+	"amg-backend/config"
+	"amg-backend/models"
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+var ErrUnknownModerationAction = errors.New("unknown moderation action")
+
+var moderationActions = map[string]string{
+	"approve": models.CommentStatusApproved,
+	"reject":  models.CommentStatusRejected,
+	"spam":    models.CommentStatusSpam,
+}
+
+// NormalizeModerationMode reports whether mode is "pre" or "post".
+func NormalizeModerationMode(mode string) (string, bool) {
+	switch mode {
+	case models.ModerationPre, models.ModerationPost:
+		return mode, true
+	}
+	return "", false
+}
+
+// ModerationModeFor returns the moderation mode of a category: its own
+// setting, else the global setting, else COMMENT.MODERATION.
+func ModerationModeFor(db *mongo.Client, category string) (string, error) {
+	collection := db.Database(config.DBName).Collection("CommentModerationSetting")
+	cursor, err := collection.Find(context.TODO(), bson.M{"category": bson.M{"$in": bson.A{category, ""}}})
+	if err != nil {
+		return "", err
+	}
+	var settings []models.CommentModerationSetting
+	if err := cursor.All(context.TODO(), &settings); err != nil {
+		return "", err
+	}
+
+	mode := config.CommentModeration
+	for _, setting := range settings {
+		if setting.Category == category {
+			return setting.Mode, nil
+		}
+		mode = setting.Mode
+	}
+	if _, ok := NormalizeModerationMode(mode); !ok {
+		return models.ModerationPre, nil
+	}
+	return mode, nil
+}
+
+// SetModerationMode stores the mode of a category ("" for all categories).
+func SetModerationMode(db *mongo.Client, category string, mode string) error {
+	collection := db.Database(config.DBName).Collection("CommentModerationSetting")
+	_, err := collection.UpdateOne(context.TODO(),
+		bson.M{"category": category},
+		bson.M{"$set": bson.M{"mode": mode, "updated_at": time.Now()}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+// ListModerationSettings returns the stored per-category settings.
+func ListModerationSettings(db *mongo.Client) ([]models.CommentModerationSetting, error) {
+	collection := db.Database(config.DBName).Collection("CommentModerationSetting")
+	cursor, err := collection.Find(context.TODO(), bson.M{}, options.Find().SetSort(bson.M{"category": 1}))
+	if err != nil {
+		return nil, err
+	}
+	settings := make([]models.CommentModerationSetting, 0)
+	err = cursor.All(context.TODO(), &settings)
+	return settings, err
+}
+
+// InitialCommentStatus decides whether a new comment on a post is published
+// or queued. Staff and trusted authors (logged-in users with enough approved
+// comments and none flagged as spam) skip the queue; otherwise the moderation
+// mode of the post's category applies. userID and role are empty for
+// anonymous authors.
+func InitialCommentStatus(db *mongo.Client, postID primitive.ObjectID, userID string, role string) (string, error) {
+	if models.IsStaffRole(role) {
+		return models.CommentStatusApproved, nil
+	}
+
+	var post models.Post
+	err := db.Database(config.DBName).Collection("Post").FindOne(context.TODO(),
+		bson.M{"_id": postID},
+		options.FindOne().SetProjection(bson.M{"category": 1}),
+	).Decode(&post)
+	if err != nil {
+		return "", err
+	}
+	mode, err := ModerationModeFor(db, post.Category)
+	if err != nil {
+		return "", err
+	}
+	if mode == models.ModerationPost {
+		return models.CommentStatusApproved, nil
+	}
+
+	if userID != "" {
+		trusted, err := isTrustedCommentAuthor(db, userID)
+		if err != nil {
+			return "", err
+		}
+		if trusted {
+			return models.CommentStatusApproved, nil
+		}
+	}
+	return models.CommentStatusPending, nil
+}
+
+func isTrustedCommentAuthor(db *mongo.Client, userID string) (bool, error) {
+	if config.CommentTrustedAfter <= 0 {
+		return false, nil
+	}
+	collection := db.Database(config.DBName).Collection("Comment")
+	spam, err := collection.CountDocuments(context.TODO(), bson.M{"author_id": userID, "status": models.CommentStatusSpam})
+	if err != nil || spam > 0 {
+		return false, err
+	}
+	approved, err := collection.CountDocuments(context.TODO(), bson.M{
+		"author_id":    userID,
+		"status":       models.CommentStatusApproved,
+		"moderated_by": bson.M{"$exists": true},
+	})
+	return approved >= int64(config.CommentTrustedAfter), err
+}
+
+// ModerateComment applies approve, reject or spam to a comment and clears its
+// report count. It reports whether the comment was found.
+func ModerateComment(db *mongo.Client, id primitive.ObjectID, action string, moderatorID string) (bool, error) {
+	status, ok := moderationActions[action]
+	if !ok {
+		return false, ErrUnknownModerationAction
+	}
+	now := time.Now()
+	var comment models.Comment
+	err := db.Database(config.DBName).Collection("Comment").FindOneAndUpdate(context.TODO(),
+		bson.M{"_id": id, "status": bson.M{"$ne": models.StatusDeleted}},
+		// Reports count again from zero once staff have looked at the comment
+		bson.M{
+			"$set":   bson.M{"status": status, "moderated_at": now, "moderated_by": moderatorID, "updated_at": now},
+			"$unset": bson.M{"report_count": "", "member_report_count": ""},
+		},
+	).Decode(&comment)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	refreshCommentStatsOf(db, comment.PostId)
+	return true, nil
+}
+
+// ModerationQueue lists comments with the given status across all posts,
+// oldest first, with the title and category of their post.
+func ModerationQueue(db *mongo.Client, status string, limit int64) ([]models.ModerationQueueItem, error) {
+	database := db.Database(config.DBName)
+	cursor, err := database.Collection("Comment").Find(context.TODO(),
+		bson.M{"status": status},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}).SetLimit(limit),
+	)
+	if err != nil {
+		return nil, err
+	}
+	var comments []models.Comment
+	if err := cursor.All(context.TODO(), &comments); err != nil {
+		return nil, err
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(comments))
+	for _, comment := range comments {
+		if id, err := primitive.ObjectIDFromHex(comment.PostId); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	postCursor, err := database.Collection("Post").Find(context.TODO(),
+		bson.M{"_id": bson.M{"$in": ids}},
+		options.Find().SetProjection(bson.M{"title": 1, "category": 1}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	var posts []models.Post
+	if err := postCursor.All(context.TODO(), &posts); err != nil {
+		return nil, err
+	}
+	postsByID := make(map[string]models.Post, len(posts))
+	for _, post := range posts {
+		postsByID[post.ID.Hex()] = post
+	}
+
+	items := make([]models.ModerationQueueItem, 0, len(comments))
+	for _, comment := range comments {
+		post := postsByID[comment.PostId]
+		items = append(items, models.ModerationQueueItem{Comment: comment, PostTitle: post.Title, PostCategory: post.Category})
+	}
+	return items, nil
+}
@@ -1,62 +1,74 @@
 package service
 
 import (
+	"amg-backend/config"
+	"amg-backend/models"
 	"bytes"
+	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"golang.org/x/net/html"
 	"log"
 	"net/http"
 	"os"
+	"regexp"
 	"strings"
+	"time"
 )
 
-func ProcessContentImages(content string, baseURL string) (string, error) {
+const (
+	// MaxInlineImageSize is the largest decoded size accepted for a single base64 image in post content.
+	MaxInlineImageSize = 5 * 1024 * 1024
+	// MaxInlineImagesPerPost limits how many base64 images a single post may carry.
+	MaxInlineImagesPerPost = 30
+)
+
+var (
+	ErrInlineImageTooLarge = errors.New("inline image exceeds size limit")
+	ErrTooManyInlineImages = errors.New("too many inline images in content")
+)
+
+// ProcessContentImages extracts base64 images from content into ./uploads and
+// rewrites their src to the public URL. It returns the rewritten content and
+// the (not yet persisted) UploadedImage records for the extracted files.
+func ProcessContentImages(content string, baseURL string) (string, []models.UploadedImage, error) {
+	if !strings.Contains(content, "data:image/") {
+		return content, nil, nil
+	}
+
 	doc, err := html.Parse(strings.NewReader(content))
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
+	var images []models.UploadedImage
+	var limitErr error
+
 	var f func(*html.Node)
 	f = func(n *html.Node) {
+		if limitErr != nil {
+			return
+		}
 		if n.Type == html.ElementNode && n.Data == "img" {
 			// Iterate over all attributes of the <img> tag
 			for i, attr := range n.Attr {
 				if attr.Key == "src" && strings.HasPrefix(attr.Val, "data:image/") {
-					// 1. Extract base64 data
-					parts := strings.Split(attr.Val, ";base64,")
-					if len(parts) != 2 {
-						continue // Not a valid base64 image
-					}
-
-					mimeType := strings.TrimPrefix(parts[0], "data:")
-					imageData, err := base64.StdEncoding.DecodeString(parts[1])
+					image, ok, err := saveInlineImage(attr.Val, len(images))
 					if err != nil {
-						log.Printf("Error decoding base64 image: %v", err)
-						continue
+						limitErr = err
+						return
 					}
-
-					// 2. Determine file extension
-					ext, ok := mimeTypeToExt(mimeType)
 					if !ok {
-						log.Printf("Unsupported MIME type: %s", mimeType)
-						continue
-					}
-
-					// 3. Save the file with a unique name
-					uniqueFilename := fmt.Sprintf("%s.%s", uuid.New().String(), ext)
-					savePath := fmt.Sprintf("./uploads/%s", uniqueFilename)
-
-					err = saveBytesToFile(imageData, savePath)
-					if err != nil {
-						log.Printf("Error saving image file: %v", err)
 						continue
 					}
+					images = append(images, image)
 
-					// 4. Replace the src attribute with the new URL
-					newURL := fmt.Sprintf("%s/uploads/%s", baseURL, uniqueFilename)
-					n.Attr[i].Val = newURL
+					// Replace the src attribute with the new URL
+					n.Attr[i].Val = baseURL + image.URL
 				}
 			}
 		}
@@ -67,19 +79,145 @@ func ProcessContentImages(content string, baseURL string) (string, error) {
 	}
 	f(doc)
 
+	if limitErr != nil {
+		removeImageFiles(images)
+		return "", nil, limitErr
+	}
+
 	// Render the modified HTML back to a string
 	var buf bytes.Buffer
 	if err := html.Render(&buf, doc); err != nil {
-		return "", err
+		removeImageFiles(images)
+		return "", nil, err
 	}
 	// html.Render wraps the content in <html><body>...</body></html>
 	// We need to extract just our content back out.
 	bodyContent, err := extractBodyContent(buf.String())
 	if err != nil {
+		removeImageFiles(images)
+		return "", nil, err
+	}
+
+	return bodyContent, images, nil
+}
+
+// saveInlineImage decodes a base64 data URI into ./uploads. saved is the
+// number of images already extracted from the same content. ok is false when
+// the URI is malformed or of an unsupported type and should be left untouched.
+func saveInlineImage(dataURI string, saved int) (models.UploadedImage, bool, error) {
+	// 1. Extract base64 data
+	parts := strings.Split(dataURI, ";base64,")
+	if len(parts) != 2 {
+		return models.UploadedImage{}, false, nil // Not a valid base64 image
+	}
+
+	if saved >= MaxInlineImagesPerPost {
+		return models.UploadedImage{}, false, ErrTooManyInlineImages
+	}
+	if base64.StdEncoding.DecodedLen(len(parts[1])) > MaxInlineImageSize+2 {
+		return models.UploadedImage{}, false, ErrInlineImageTooLarge
+	}
+
+	mimeType := strings.TrimPrefix(parts[0], "data:")
+	imageData, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		log.Printf("Error decoding base64 image: %v", err)
+		return models.UploadedImage{}, false, nil
+	}
+	if len(imageData) > MaxInlineImageSize {
+		return models.UploadedImage{}, false, ErrInlineImageTooLarge
+	}
+
+	// 2. Determine file extension
+	ext, ok := mimeTypeToExt(mimeType)
+	if !ok {
+		log.Printf("Unsupported MIME type: %s", mimeType)
+		return models.UploadedImage{}, false, nil
+	}
+
+	// 3. Save the file with a unique name
+	uniqueFilename := fmt.Sprintf("%s.%s", uuid.New().String(), ext)
+	savePath := fmt.Sprintf("./uploads/%s", uniqueFilename)
+
+	if err := saveBytesToFile(imageData, savePath); err != nil {
+		log.Printf("Error saving image file: %v", err)
+		return models.UploadedImage{}, false, nil
+	}
+
+	return models.UploadedImage{
+		ID:        primitive.NewObjectID(),
+		Filename:  uniqueFilename,
+		Path:      savePath,
+		URL:       fmt.Sprintf("/uploads/%s", uniqueFilename),
+		Status:    models.ImageStatusPending,
+		CreatedAt: time.Now(),
+	}, true, nil
+}
+
+var markdownDataImageRegex = regexp.MustCompile(`data:image/[A-Za-z0-9.+-]+;base64,[A-Za-z0-9+/=]+`)
+
+// ProcessMarkdownImages is the Markdown counterpart of ProcessContentImages:
+// base64 data URIs in the source are extracted into ./uploads and replaced
+// by their public URL.
+func ProcessMarkdownImages(source string, baseURL string) (string, []models.UploadedImage, error) {
+	if !strings.Contains(source, "data:image/") {
+		return source, nil, nil
+	}
+
+	var images []models.UploadedImage
+	var limitErr error
+	newSource := markdownDataImageRegex.ReplaceAllStringFunc(source, func(dataURI string) string {
+		if limitErr != nil {
+			return dataURI
+		}
+		image, ok, err := saveInlineImage(dataURI, len(images))
+		if err != nil {
+			limitErr = err
+			return dataURI
+		}
+		if !ok {
+			return dataURI
+		}
+		images = append(images, image)
+		return baseURL + image.URL
+	})
+
+	if limitErr != nil {
+		removeImageFiles(images)
+		return "", nil, limitErr
+	}
+	return newSource, images, nil
+}
+
+// ProcessAndRecordContentImages runs ProcessContentImages (or
+// ProcessMarkdownImages for Markdown content) and stores an UploadedImage
+// record for every extracted file. The records start as 'pending';
+// MarkImagesAsUsed flips them once the post has been saved.
+func ProcessAndRecordContentImages(db *mongo.Client, content string, format string) (string, error) {
+	process := ProcessContentImages
+	if format == models.ContentFormatMarkdown {
+		process = ProcessMarkdownImages
+	}
+	newContent, images, err := process(content, config.BaseURL)
+	if err != nil {
+		return "", err
+	}
+	if len(images) == 0 {
+		return newContent, nil
+	}
+
+	docs := make([]interface{}, len(images))
+	for i := range images {
+		docs[i] = images[i]
+	}
+
+	collection := db.Database(config.DBName).Collection("UploadedImage")
+	if _, err := collection.InsertMany(context.TODO(), docs); err != nil {
+		removeImageFiles(images)
 		return "", err
 	}
 
-	return bodyContent, nil
+	return newContent, nil
 }
 
 // Helper to get extension from MIME type
@@ -115,6 +253,15 @@ func saveBytesToFile(data []byte, path string) error {
 	return err
 }
 
+// Helper to remove files written for images that will not be recorded
+func removeImageFiles(images []models.UploadedImage) {
+	for _, image := range images {
+		if err := os.Remove(image.Path); err != nil && !os.IsNotExist(err) {
+			log.Printf("Warning: could not remove image file %s: %v", image.Path, err)
+		}
+	}
+}
+
 // Helper to extract content from <body> tag generated by html.Render
 func extractBodyContent(fullHTML string) (string, error) {
 	doc, err := html.Parse(strings.NewReader(fullHTML))
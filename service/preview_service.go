@@ -0,0 +1,100 @@
+package service
+
+import (
+	"amg-backend/config"
+	"amg-backend/models"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultPreviewTTL is how long a preview link stays valid when no duration is given.
+	DefaultPreviewTTL = 72 * time.Hour
+	// MaxPreviewTTL is the longest validity allowed for a preview link.
+	MaxPreviewTTL = 14 * 24 * time.Hour
+)
+
+var (
+	ErrInvalidPreviewToken = errors.New("invalid preview token")
+	ErrExpiredPreviewToken = errors.New("preview token expired")
+)
+
+func previewSignature(payload string) []byte {
+	mac := hmac.New(sha256.New, []byte("post-preview:"+config.SecretKey))
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+// GeneratePreviewToken signs a token granting read access to one post until
+// expiresAt. version must match the post's PreviewVersion when verifying.
+func GeneratePreviewToken(postID primitive.ObjectID, version int, expiresAt time.Time) string {
+	payload := fmt.Sprintf("%s.%d.%d", postID.Hex(), version, expiresAt.Unix())
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." +
+		base64.RawURLEncoding.EncodeToString(previewSignature(payload))
+}
+
+// VerifyPreviewToken checks that token was issued for postID with the given
+// version and has not expired.
+func VerifyPreviewToken(token string, postID primitive.ObjectID, version int) error {
+	encodedPayload, encodedSignature, ok := strings.Cut(token, ".")
+	if !ok {
+		return ErrInvalidPreviewToken
+	}
+	payloadBytes, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return ErrInvalidPreviewToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil {
+		return ErrInvalidPreviewToken
+	}
+
+	payload := string(payloadBytes)
+	if !hmac.Equal(signature, previewSignature(payload)) {
+		return ErrInvalidPreviewToken
+	}
+
+	parts := strings.Split(payload, ".")
+	if len(parts) != 3 || parts[0] != postID.Hex() || parts[1] != strconv.Itoa(version) {
+		return ErrInvalidPreviewToken
+	}
+	expiresAt, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return ErrInvalidPreviewToken
+	}
+	if time.Now().Unix() > expiresAt {
+		return ErrExpiredPreviewToken
+	}
+	return nil
+}
+
+// PreviewTokenGrants reports whether token is a valid preview link for the
+// post, so that the files of a previewed draft can be downloaded too.
+func PreviewTokenGrants(db *mongo.Client, postID primitive.ObjectID, token string) (bool, error) {
+	if token == "" {
+		return false, nil
+	}
+	var post models.Post
+	err := db.Database(config.DBName).Collection("Post").FindOne(context.TODO(),
+		bson.M{"_id": postID},
+		options.FindOne().SetProjection(bson.M{"preview_version": 1}),
+	).Decode(&post)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return VerifyPreviewToken(token, postID, post.PreviewVersion) == nil, nil
+}
@@ -0,0 +1,283 @@
+package service
+
+import (
+	"amg-backend/config"
+	"amg-backend/models"
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// MaxRelatedPosts is the number of recommendations cached per post.
+	MaxRelatedPosts = 8
+
+	titleTermWeight      = 3.0
+	textSimilarityWeight = 0.6
+	sameCategoryWeight   = 0.25
+	sharedTagsWeight     = 0.15
+	minRelatedScore      = 0.05
+
+	// A refresh waits until posts have not changed for relatedRefreshDelay, so
+	// a burst of edits recomputes once, but never longer than relatedRefreshMaxWait.
+	relatedRefreshDelay   = 30 * time.Second
+	relatedRefreshMaxWait = 5 * time.Minute
+)
+
+var relatedPostsMu sync.Mutex
+
+var (
+	relatedRefreshMu      sync.Mutex
+	relatedRefreshTimer   *time.Timer
+	relatedRefreshPending time.Time
+)
+
+type postVector struct {
+	post    models.Post
+	weights map[string]float64
+	norm    float64
+	tags    map[string]bool
+}
+
+// buildPostVectors computes a TF-IDF vector for every post. Title terms count
+// more than body terms.
+func buildPostVectors(posts []models.Post) []postVector {
+	termFreqs := make([]map[string]float64, len(posts))
+	docFreq := make(map[string]int)
+
+	for i, post := range posts {
+		tf := make(map[string]float64)
+		for _, token := range Tokenize(post.Title) {
+			tf[token] += titleTermWeight
+		}
+		for _, token := range Tokenize(PlainText(post.Content)) {
+			tf[token]++
+		}
+		for term := range tf {
+			docFreq[term]++
+		}
+		termFreqs[i] = tf
+	}
+
+	n := float64(len(posts))
+	vectors := make([]postVector, len(posts))
+	for i, post := range posts {
+		var total float64
+		for _, count := range termFreqs[i] {
+			total += count
+		}
+
+		weights := make(map[string]float64, len(termFreqs[i]))
+		var sumSquares float64
+		for term, count := range termFreqs[i] {
+			w := (count / total) * math.Log(1+n/float64(docFreq[term]))
+			weights[term] = w
+			sumSquares += w * w
+		}
+
+		tags := make(map[string]bool, len(post.Tags))
+		for _, tag := range post.Tags {
+			tags[strings.ToLower(tag)] = true
+		}
+
+		vectors[i] = postVector{post: post, weights: weights, norm: math.Sqrt(sumSquares), tags: tags}
+	}
+	return vectors
+}
+
+func cosineSimilarity(a, b postVector) float64 {
+	if a.norm == 0 || b.norm == 0 {
+		return 0
+	}
+	if len(a.weights) > len(b.weights) {
+		a, b = b, a
+	}
+	var dot float64
+	for term, w := range a.weights {
+		dot += w * b.weights[term]
+	}
+	return dot / (a.norm * b.norm)
+}
+
+func tagSimilarity(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	shared := 0
+	for tag := range a {
+		if b[tag] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
+
+func relatedScore(a, b postVector) float64 {
+	score := textSimilarityWeight * cosineSimilarity(a, b)
+	if a.post.Category != "" && a.post.Category == b.post.Category {
+		score += sameCategoryWeight
+	}
+	score += sharedTagsWeight * tagSimilarity(a.tags, b.tags)
+	return score
+}
+
+// computeRelated ranks the public posts related to vectors[index]. Related
+// posts are shown to everyone, so restricted posts are never ranked and never
+// take a place from a public one.
+func computeRelated(vectors []postVector, index int) []models.RelatedPostScore {
+	related := make([]models.RelatedPostScore, 0)
+	for j := range vectors {
+		if j == index || !CanViewPost(PostViewer{}, vectors[j].post) {
+			continue
+		}
+		score := relatedScore(vectors[index], vectors[j])
+		if score < minRelatedScore {
+			continue
+		}
+		related = append(related, models.RelatedPostScore{
+			PostID: vectors[j].post.ID,
+			Score:  math.Round(score*10000) / 10000,
+		})
+	}
+
+	sort.SliceStable(related, func(i, j int) bool {
+		return related[i].Score > related[j].Score
+	})
+	if len(related) > MaxRelatedPosts {
+		related = related[:MaxRelatedPosts]
+	}
+	return related
+}
+
+func loadActivePosts(db *mongo.Client) ([]models.Post, error) {
+	collection := db.Database(config.DBName).Collection("Post")
+	findOptions := options.Find().SetSort(bson.D{{Key: "create_at", Value: -1}})
+	cursor, err := collection.Find(context.TODO(), bson.M{"status": models.PostStatusActive}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	var posts []models.Post
+	err = cursor.All(context.TODO(), &posts)
+	return posts, err
+}
+
+// RefreshRelatedPosts recomputes and caches the related posts of every active post.
+func RefreshRelatedPosts(db *mongo.Client) error {
+	relatedPostsMu.Lock()
+	defer relatedPostsMu.Unlock()
+
+	posts, err := loadActivePosts(db)
+	if err != nil {
+		return err
+	}
+
+	collection := db.Database(config.DBName).Collection("RelatedPosts")
+	vectors := buildPostVectors(posts)
+	now := time.Now()
+
+	ids := make([]primitive.ObjectID, len(posts))
+	writes := make([]mongo.WriteModel, 0, len(posts))
+	for i := range vectors {
+		ids[i] = vectors[i].post.ID
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"post_id": vectors[i].post.ID}).
+			SetUpdate(bson.M{"$set": bson.M{"related": computeRelated(vectors, i), "computed_at": now}}).
+			SetUpsert(true))
+	}
+	if len(writes) > 0 {
+		if _, err := collection.BulkWrite(context.TODO(), writes, options.BulkWrite().SetOrdered(false)); err != nil {
+			return err
+		}
+	}
+
+	// Drop the cache of posts that are no longer active
+	_, err = collection.DeleteMany(context.TODO(), bson.M{"post_id": bson.M{"$nin": ids}})
+	return err
+}
+
+// RefreshRelatedPostsAsync schedules RefreshRelatedPosts in the background
+// once posts stop changing. Calls while a refresh is pending are merged.
+func RefreshRelatedPostsAsync(db *mongo.Client) {
+	relatedRefreshMu.Lock()
+	defer relatedRefreshMu.Unlock()
+
+	if relatedRefreshTimer != nil {
+		if time.Since(relatedRefreshPending) < relatedRefreshMaxWait {
+			relatedRefreshTimer.Reset(relatedRefreshDelay)
+		}
+		return
+	}
+	relatedRefreshPending = time.Now()
+	relatedRefreshTimer = time.AfterFunc(relatedRefreshDelay, func() {
+		relatedRefreshMu.Lock()
+		relatedRefreshTimer = nil
+		relatedRefreshMu.Unlock()
+
+		if err := RefreshRelatedPosts(db); err != nil {
+			log.Printf("Warning: could not refresh related posts: %v", err)
+		}
+	})
+}
+
+// GetRelatedPosts returns up to limit public active posts related to postID,
+// best match first. Posts not in the cache yet have no related posts until the
+// next refresh by the cron job or after a post changes.
+func GetRelatedPosts(db *mongo.Client, postID primitive.ObjectID, limit int) ([]models.Post, error) {
+	database := db.Database(config.DBName)
+
+	var cached models.RelatedPosts
+	err := database.Collection("RelatedPosts").FindOne(context.TODO(), bson.M{"post_id": postID}).Decode(&cached)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+
+	related := make([]models.Post, 0, limit)
+	if len(cached.Related) == 0 {
+		return related, nil
+	}
+
+	ids := make([]primitive.ObjectID, len(cached.Related))
+	for i, r := range cached.Related {
+		ids[i] = r.PostID
+	}
+	// The cache only holds public posts, but a post may have been restricted
+	// since it was computed
+	filter := PublicPostFilter()
+	filter["_id"] = bson.M{"$in": ids}
+	filter["status"] = models.PostStatusActive
+	cursor, err := database.Collection("Post").Find(context.TODO(), filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	var posts []models.Post
+	if err := cursor.All(context.TODO(), &posts); err != nil {
+		return nil, err
+	}
+	postsByID := make(map[primitive.ObjectID]models.Post, len(posts))
+	for _, post := range posts {
+		postsByID[post.ID] = post
+	}
+
+	for _, r := range cached.Related {
+		if post, ok := postsByID[r.PostID]; ok {
+			related = append(related, post)
+			if len(related) == limit {
+				break
+			}
+		}
+	}
+	return related, nil
+}
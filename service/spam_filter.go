@@ -0,0 +1,226 @@
+package service
+
+import (
+	"amg-backend/config"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Submission is what the content filters look at: the free-text fields of a
+// public form and the value of its honeypot field, a hidden "website" field
+// that people never fill in and naive bots do.
+type Submission struct {
+	Fields   []string
+	Honeypot string
+}
+
+// FilterResult is the score one filter gives a submission, with a short
+// reason for staff reviewing it. A score of 0 means nothing suspicious.
+type FilterResult struct {
+	Score  float64
+	Reason string
+}
+
+// ContentFilter is one step of the spam pipeline. Filters are independent and
+// their scores are summed; see RegisterContentFilter.
+type ContentFilter interface {
+	Check(sub Submission) FilterResult
+}
+
+// ContentFilterFunc adapts a function to ContentFilter.
+type ContentFilterFunc func(sub Submission) FilterResult
+
+func (f ContentFilterFunc) Check(sub Submission) FilterResult {
+	return f(sub)
+}
+
+// SpamVerdict is the outcome of running the pipeline.
+type SpamVerdict struct {
+	Score   float64
+	Reasons []string
+	IsSpam  bool
+}
+
+var (
+	contentFiltersMu sync.RWMutex
+	contentFilters   = []ContentFilter{
+		ContentFilterFunc(honeypotFilter),
+		ContentFilterFunc(profanityFilter),
+		ContentFilterFunc(linkFilter),
+		ContentFilterFunc(repetitionFilter),
+	}
+)
+
+// RegisterContentFilter adds a filter to the pipeline used by CheckSubmission.
+func RegisterContentFilter(filter ContentFilter) {
+	contentFiltersMu.Lock()
+	defer contentFiltersMu.Unlock()
+	contentFilters = append(contentFilters, filter)
+}
+
+// CheckSubmission runs every content filter. Submissions scoring at least
+// SPAM.THRESHOLD are spam; callers store them with a spam status for staff
+// to review instead of rejecting them, so false positives are not lost.
+func CheckSubmission(sub Submission) SpamVerdict {
+	contentFiltersMu.RLock()
+	filters := contentFilters
+	contentFiltersMu.RUnlock()
+
+	verdict := SpamVerdict{Reasons: []string{}}
+	for _, filter := range filters {
+		result := filter.Check(sub)
+		if result.Score <= 0 {
+			continue
+		}
+		verdict.Score += result.Score
+		verdict.Reasons = append(verdict.Reasons, result.Reason)
+	}
+	threshold := config.SpamThreshold
+	if threshold <= 0 {
+		threshold = 1
+	}
+	verdict.IsSpam = verdict.Score >= threshold
+	return verdict
+}
+
+func honeypotFilter(sub Submission) FilterResult {
+	if strings.TrimSpace(sub.Honeypot) != "" {
+		return FilterResult{Score: 10, Reason: "honeypot field filled in"}
+	}
+	return FilterResult{}
+}
+
+// profanityWords are matched against whole words; entries with a space are
+// matched as phrases. Unaccented Vietnamese is only listed where it cannot be
+// mistaken for an everyday word.
+var profanityWords = []string{
+	// Vietnamese
+	"địt", "đụ", "đéo", "đếch", "lồn", "buồi", "cặc", "đĩ", "đm", "đmm", "đcm", "dcm", "dmm",
+	"vcl", "vkl", "vcc", "clgt", "cmm", "óc chó", "mẹ mày", "thằng chó", "súc vật",
+	// English
+	"fuck", "fucking", "fucker", "motherfucker", "shit", "bullshit", "bitch", "asshole",
+	"bastard", "cunt", "dick", "slut", "whore",
+	// Common spam vocabulary
+	"viagra", "casino", "porn", "xxx", "cá độ", "nhà cái", "tài xỉu",
+}
+
+var wordRegex = regexp.MustCompile(`[\p{L}\p{N}]+`)
+
+func normalizedWords(text string) []string {
+	return wordRegex.FindAllString(strings.ToLower(text), -1)
+}
+
+func profanityFilter(sub Submission) FilterResult {
+	words := make(map[string]bool)
+	var joined []string
+	for _, field := range sub.Fields {
+		tokens := normalizedWords(field)
+		for _, token := range tokens {
+			words[token] = true
+		}
+		joined = append(joined, strings.Join(tokens, " "))
+	}
+	text := " " + strings.Join(joined, " | ") + " "
+
+	terms := append([]string{}, profanityWords...)
+	for _, extra := range strings.Split(config.SpamExtraWords, ",") {
+		if extra = strings.ToLower(strings.TrimSpace(extra)); extra != "" {
+			terms = append(terms, extra)
+		}
+	}
+
+	var hits []string
+	for _, term := range terms {
+		if strings.Contains(term, " ") {
+			if strings.Contains(text, " "+term+" ") {
+				hits = append(hits, term)
+			}
+		} else if words[term] {
+			hits = append(hits, term)
+		}
+	}
+	if len(hits) == 0 {
+		return FilterResult{}
+	}
+	return FilterResult{Score: 0.5 * float64(len(hits)), Reason: "blocked words: " + strings.Join(hits, ", ")}
+}
+
+var linkRegex = regexp.MustCompile(`(?i)(https?://|www\.|\b[a-z0-9-]+\.(com|net|org|info|xyz|top|vip|club|shop|online|site)\b)`)
+
+func linkFilter(sub Submission) FilterResult {
+	links := 0
+	for _, field := range sub.Fields {
+		links += len(linkRegex.FindAllString(field, -1))
+	}
+	switch {
+	case links == 0:
+		return FilterResult{}
+	case links == 1:
+		return FilterResult{Score: 0.3, Reason: "contains a link"}
+	case links == 2:
+		return FilterResult{Score: 0.6, Reason: "contains 2 links"}
+	}
+	return FilterResult{Score: 1, Reason: "contains many links"}
+}
+
+func repetitionFilter(sub Submission) FilterResult {
+	text := strings.Join(sub.Fields, "\n")
+	score := 0.0
+	var reasons []string
+
+	if hasRepeatedRun(text) {
+		score += 0.4
+		reasons = append(reasons, "repeated characters")
+	}
+
+	words := normalizedWords(text)
+	if len(words) >= 8 {
+		distinct := make(map[string]bool)
+		for _, word := range words {
+			distinct[word] = true
+		}
+		if float64(len(distinct))/float64(len(words)) < 0.3 {
+			score += 0.6
+			reasons = append(reasons, "repeated words")
+		}
+	}
+
+	letters, upper := 0, 0
+	for _, r := range text {
+		if unicode.IsLetter(r) {
+			letters++
+			if unicode.IsUpper(r) {
+				upper++
+			}
+		}
+	}
+	if letters >= 20 && float64(upper)/float64(letters) > 0.7 {
+		score += 0.3
+		reasons = append(reasons, "mostly capitals")
+	}
+
+	if score == 0 {
+		return FilterResult{}
+	}
+	return FilterResult{Score: score, Reason: strings.Join(reasons, ", ")}
+}
+
+// hasRepeatedRun reports whether a character repeats 8 or more times in a
+// row. Go's regexp has no backreferences, so this is done by hand.
+func hasRepeatedRun(text string) bool {
+	var last rune
+	run := 0
+	for _, r := range text {
+		if r == last && !unicode.IsSpace(r) {
+			run++
+			if run >= 8 {
+				return true
+			}
+		} else {
+			last, run = r, 1
+		}
+	}
+	return false
+}
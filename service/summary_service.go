@@ -0,0 +1,125 @@
+package service
+
+import (
+	"amg-backend/config"
+	"amg-backend/models"
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"strings"
+	"unicode"
+)
+
+const (
+	// ExcerptLength is the maximum length, in characters, of a generated excerpt.
+	ExcerptLength = 200
+	// WordsPerMinute is the reading speed used to estimate reading time.
+	WordsPerMinute = 200
+)
+
+// BuildExcerpt shortens text to at most maxLength characters, cutting at a
+// word boundary and adding an ellipsis when something was cut.
+func BuildExcerpt(text string, maxLength int) string {
+	runes := []rune(strings.TrimSpace(text))
+	if len(runes) <= maxLength {
+		return string(runes)
+	}
+
+	cut := maxLength
+	for cut > maxLength/2 && !unicode.IsSpace(runes[cut]) {
+		cut--
+	}
+	excerpt := strings.TrimRightFunc(string(runes[:cut]), func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsPunct(r)
+	})
+	return excerpt + "…"
+}
+
+// absoluteURL prefixes site-relative paths with BASE_URL, as required by Open Graph.
+func absoluteURL(path string) string {
+	if path == "" || strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		return path
+	}
+	return config.BaseURL + path
+}
+
+// ApplyPostSummary fills the excerpt, word count, reading time and Open Graph
+// metadata of a post from its title, content and header image. Editor
+// overrides take precedence over generated values.
+func ApplyPostSummary(post *models.Post) {
+	text := PlainText(post.Content)
+
+	post.WordCount = len(strings.Fields(text))
+	post.ReadingTime = 0
+	if post.WordCount > 0 {
+		post.ReadingTime = (post.WordCount + WordsPerMinute - 1) / WordsPerMinute
+	}
+
+	post.Excerpt = BuildExcerpt(text, ExcerptLength)
+	if post.ExcerptOverride != "" {
+		post.Excerpt = post.ExcerptOverride
+	}
+
+	image := post.HeaderImage
+	if image == "" {
+		if urls := ExtractImageUrls(post.Content); len(urls) > 0 {
+			image = urls[0]
+		}
+	}
+
+	post.Meta = models.PostMeta{
+		Title:       post.Title,
+		Description: BuildExcerpt(post.Excerpt, 160),
+		Image:       absoluteURL(image),
+		TwitterCard: "summary",
+	}
+	if post.MetaOverrides.Title != "" {
+		post.Meta.Title = post.MetaOverrides.Title
+	}
+	if post.MetaOverrides.Description != "" {
+		post.Meta.Description = post.MetaOverrides.Description
+	}
+	if post.MetaOverrides.Image != "" {
+		post.Meta.Image = absoluteURL(post.MetaOverrides.Image)
+	}
+	if post.Meta.Image != "" {
+		post.Meta.TwitterCard = "summary_large_image"
+	}
+}
+
+// PostSummaryFields returns the generated fields of a post as a $set document.
+func PostSummaryFields(post models.Post) bson.M {
+	return bson.M{
+		"excerpt":          post.Excerpt,
+		"word_count":       post.WordCount,
+		"reading_time":     post.ReadingTime,
+		"meta":             post.Meta,
+		"excerpt_override": post.ExcerptOverride,
+		"meta_overrides":   post.MetaOverrides,
+	}
+}
+
+// RegeneratePostSummaries recomputes the generated fields of every post, e.g.
+// for posts created before they existed. It returns the number of posts updated.
+func RegeneratePostSummaries(db *mongo.Client) (int, error) {
+	collection := db.Database(config.DBName).Collection("Post")
+	cursor, err := collection.Find(context.TODO(), bson.M{})
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(context.TODO())
+
+	updated := 0
+	for cursor.Next(context.TODO()) {
+		var post models.Post
+		if err := cursor.Decode(&post); err != nil {
+			return updated, err
+		}
+		ApplyPostSummary(&post)
+		if _, err := collection.UpdateByID(context.TODO(), post.ID, bson.M{"$set": PostSummaryFields(post)}); err != nil {
+			return updated, err
+		}
+		updated++
+	}
+	return updated, cursor.Err()
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"golang.org/x/net/html"
+	"strings"
+	"unicode"
+)
+
+// PlainText strips the markup from HTML content and collapses whitespace.
+func PlainText(content string) string {
+	tokenizer := html.NewTokenizer(strings.NewReader(content))
+	var sb strings.Builder
+	skip := 0
+
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return strings.Join(strings.Fields(sb.String()), " ")
+		case html.StartTagToken:
+			name, _ := tokenizer.TagName()
+			switch string(name) {
+			case "script", "style":
+				skip++
+			case "br", "p", "div", "li", "h1", "h2", "h3", "h4", "h5", "h6", "tr", "td", "blockquote":
+				sb.WriteByte(' ')
+			}
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			switch string(name) {
+			case "script", "style":
+				if skip > 0 {
+					skip--
+				}
+			case "p", "div", "li", "h1", "h2", "h3", "h4", "h5", "h6", "tr", "td", "blockquote":
+				sb.WriteByte(' ')
+			}
+		case html.SelfClosingTagToken:
+			sb.WriteByte(' ')
+		case html.TextToken:
+			if skip == 0 {
+				sb.Write(tokenizer.Text())
+			}
+		}
+	}
+}
+
+var stopWords = map[string]bool{
+	// English
+	"the": true, "and": true, "for": true, "are": true, "was": true, "with": true, "this": true,
+	"that": true, "from": true, "have": true, "has": true, "our": true, "your": true, "you": true,
+	"will": true, "not": true, "but": true, "all": true, "can": true, "they": true, "their": true,
+	// Vietnamese
+	"và": true, "của": true, "là": true, "các": true, "có": true, "cho": true, "những": true,
+	"được": true, "trong": true, "với": true, "một": true, "này": true, "để": true, "không": true,
+	"đã": true, "khi": true, "thì": true, "từ": true, "ra": true, "vào": true, "cũng": true,
+	"như": true, "đến": true, "sẽ": true, "về": true, "tại": true, "nhưng": true, "rất": true,
+	"nhiều": true, "còn": true, "mà": true, "hay": true, "lại": true, "đó": true, "theo": true,
+}
+
+// Tokenize splits plain text into lower-case words, dropping stop words and
+// single characters.
+func Tokenize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	tokens := words[:0]
+	for _, word := range words {
+		if len([]rune(word)) < 2 || stopWords[word] {
+			continue
+		}
+		tokens = append(tokens, word)
+	}
+	return tokens
+}
@@ -0,0 +1,476 @@
+package service
+
+import (
+	"amg-backend/config"
+	"amg-backend/models"
+	"context"
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"sort"
+	"strings"
+	"time"
+	_ "time/tzdata"
+)
+
+// MaxTourDays is how far ahead tour slots can be listed and booked.
+const MaxTourDays = 60
+
+// PublicFormAuthor is the author recorded for changes made through the
+// public candidate form.
+const PublicFormAuthor = "website"
+
+// SystemAuthor is the author recorded for changes the server makes on its
+// own, such as cancelling the tour of a deleted candidate.
+const SystemAuthor = "system"
+
+var (
+	ErrInvalidTourRule    = errors.New("invalid tour slot rule")
+	ErrTourRuleNotFound   = errors.New("tour slot rule not found")
+	ErrTourSlotNotFound   = errors.New("tour slot not found")
+	ErrTourSlotFull       = errors.New("tour slot is full")
+	ErrTourAlreadyBooked  = errors.New("candidate already has a tour booked")
+	ErrTourBookingMissing = errors.New("tour booking not found")
+)
+
+// TourLocation is the time zone tour slots are defined in.
+func TourLocation() *time.Location {
+	if config.TourTimezone != "" {
+		if loc, err := time.LoadLocation(config.TourTimezone); err == nil {
+			return loc
+		}
+	}
+	return time.FixedZone("ICT", 7*60*60)
+}
+
+func parseClock(value string) (int, int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, 0, ErrInvalidTourRule
+	}
+	return t.Hour(), t.Minute(), nil
+}
+
+// ValidateTourRule checks a rule payload and returns the rule it describes.
+func ValidateTourRule(payload models.TourSlotRulePayload) (models.TourSlotRule, error) {
+	rule := models.TourSlotRule{
+		Campus:          strings.TrimSpace(payload.Campus),
+		Weekday:         payload.Weekday,
+		StartTime:       strings.TrimSpace(payload.StartTime),
+		DurationMinutes: payload.DurationMinutes,
+		Capacity:        payload.Capacity,
+		Active:          payload.Active == nil || *payload.Active,
+	}
+	if rule.DurationMinutes == 0 {
+		rule.DurationMinutes = 60
+	}
+	if rule.Campus == "" || rule.Weekday < 0 || rule.Weekday > 6 ||
+		rule.DurationMinutes < 0 || rule.DurationMinutes > 8*60 || rule.Capacity < 1 {
+		return rule, ErrInvalidTourRule
+	}
+	if _, _, err := parseClock(rule.StartTime); err != nil {
+		return rule, err
+	}
+	return rule, nil
+}
+
+// CreateTourRule stores a new recurring tour slot and generates its slots for
+// the next MaxTourDays days.
+func CreateTourRule(db *mongo.Client, rule models.TourSlotRule) (models.TourSlotRule, error) {
+	rule.ID = primitive.NewObjectID()
+	rule.CreatedAt = time.Now()
+	rule.UpdatedAt = rule.CreatedAt
+	if _, err := db.Database(config.DBName).Collection("TourSlotRule").InsertOne(context.TODO(), rule); err != nil {
+		return rule, err
+	}
+	return rule, ensureTourSlots(db, bson.M{"_id": rule.ID}, rule.CreatedAt, rule.CreatedAt.AddDate(0, 0, MaxTourDays))
+}
+
+// GenerateTourSlots creates the slots of every active rule for the next
+// MaxTourDays days. The daily cron job calls it so that the bookable window
+// moves forward; slots that already exist are left as they are.
+func GenerateTourSlots(db *mongo.Client) error {
+	now := time.Now()
+	return ensureTourSlots(db, bson.M{}, now, now.AddDate(0, 0, MaxTourDays))
+}
+
+// UpdateTourRule replaces a rule. Future slots generated from it that nobody
+// booked are dropped so they are generated again from the new rule; booked
+// ones stay as they are, with the new capacity.
+func UpdateTourRule(db *mongo.Client, id primitive.ObjectID, rule models.TourSlotRule) (models.TourSlotRule, error) {
+	database := db.Database(config.DBName)
+	var existing models.TourSlotRule
+	err := database.Collection("TourSlotRule").FindOne(context.TODO(), bson.M{"_id": id}).Decode(&existing)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return rule, ErrTourRuleNotFound
+	}
+	if err != nil {
+		return rule, err
+	}
+
+	rule.ID = id
+	rule.CreatedAt = existing.CreatedAt
+	rule.UpdatedAt = time.Now()
+	if _, err := database.Collection("TourSlotRule").ReplaceOne(context.TODO(), bson.M{"_id": id}, rule); err != nil {
+		return rule, err
+	}
+
+	slots := database.Collection("TourSlot")
+	now := time.Now()
+	if _, err := slots.DeleteMany(context.TODO(), bson.M{"rule_id": id, "start_at": bson.M{"$gt": now}, "booked": 0}); err != nil {
+		return rule, err
+	}
+	_, err = slots.UpdateMany(context.TODO(),
+		bson.M{"rule_id": id, "start_at": bson.M{"$gt": now}},
+		bson.M{"$set": bson.M{"capacity": rule.Capacity}},
+	)
+	if err != nil {
+		return rule, err
+	}
+	return rule, ensureTourSlots(db, bson.M{"_id": id}, now, now.AddDate(0, 0, MaxTourDays))
+}
+
+// ListTourRules returns the recurring tour slots, optionally of one campus.
+func ListTourRules(db *mongo.Client, campus string) ([]models.TourSlotRule, error) {
+	filter := bson.M{}
+	if campus != "" {
+		filter["campus"] = campus
+	}
+	cursor, err := db.Database(config.DBName).Collection("TourSlotRule").Find(context.TODO(), filter,
+		options.Find().SetSort(bson.D{{Key: "campus", Value: 1}, {Key: "weekday", Value: 1}, {Key: "start_time", Value: 1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	rules := make([]models.TourSlotRule, 0)
+	if err := cursor.All(context.TODO(), &rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// ensureTourSlots generates the slots of the active rules matching filter
+// between from and to. Generating is idempotent: slots are keyed by rule and
+// start time.
+func ensureTourSlots(db *mongo.Client, filter bson.M, from time.Time, to time.Time) error {
+	filter["active"] = true
+	database := db.Database(config.DBName)
+	cursor, err := database.Collection("TourSlotRule").Find(context.TODO(), filter)
+	if err != nil {
+		return err
+	}
+	var rules []models.TourSlotRule
+	if err := cursor.All(context.TODO(), &rules); err != nil {
+		return err
+	}
+
+	loc := TourLocation()
+	from, to = from.In(loc), to.In(loc)
+	slots := database.Collection("TourSlot")
+	for _, rule := range rules {
+		hour, minute, err := parseClock(rule.StartTime)
+		if err != nil {
+			continue
+		}
+		for day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, loc); !day.After(to); day = day.AddDate(0, 0, 1) {
+			if int(day.Weekday()) != rule.Weekday {
+				continue
+			}
+			startAt := time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, loc)
+			if startAt.Before(from) || startAt.After(to) {
+				continue
+			}
+			_, err := slots.UpdateOne(context.TODO(),
+				bson.M{"rule_id": rule.ID, "start_at": startAt},
+				bson.M{"$setOnInsert": models.TourSlot{
+					ID:       primitive.NewObjectID(),
+					RuleID:   rule.ID,
+					Campus:   rule.Campus,
+					StartAt:  startAt,
+					EndAt:    startAt.Add(time.Duration(rule.DurationMinutes) * time.Minute),
+					Capacity: rule.Capacity,
+				}},
+				options.Update().SetUpsert(true),
+			)
+			if err != nil && !mongo.IsDuplicateKeyError(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// AvailableTourSlots lists the upcoming slots with free places over the next
+// days, optionally of one campus, soonest first. It only reads; slots are
+// generated when rules change and by the daily cron job.
+func AvailableTourSlots(db *mongo.Client, campus string, days int) ([]models.TourSlot, error) {
+	now := time.Now()
+	to := now.AddDate(0, 0, days)
+
+	// Slots of deactivated rules stay for their bookings but take no more
+	activeRules, err := db.Database(config.DBName).Collection("TourSlotRule").Distinct(context.TODO(), "_id", bson.M{"active": true})
+	if err != nil {
+		return nil, err
+	}
+	filter := bson.M{
+		"rule_id":  bson.M{"$in": activeRules},
+		"start_at": bson.M{"$gt": now, "$lte": to},
+		"$expr":    bson.M{"$lt": bson.A{"$booked", "$capacity"}},
+	}
+	if campus != "" {
+		filter["campus"] = campus
+	}
+	cursor, err := db.Database(config.DBName).Collection("TourSlot").Find(context.TODO(), filter,
+		options.Find().SetSort(bson.D{{Key: "start_at", Value: 1}, {Key: "campus", Value: 1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	slots := make([]models.TourSlot, 0)
+	if err := cursor.All(context.TODO(), &slots); err != nil {
+		return nil, err
+	}
+	return slots, nil
+}
+
+// reserveTourSlot takes a place on an upcoming slot of an active rule. The
+// capacity check and the increment are one update, so two families can never
+// get the last place.
+func reserveTourSlot(db *mongo.Client, slotID primitive.ObjectID) (models.TourSlot, error) {
+	database := db.Database(config.DBName)
+	collection := database.Collection("TourSlot")
+	var slot models.TourSlot
+	err := collection.FindOne(context.TODO(), bson.M{"_id": slotID, "start_at": bson.M{"$gt": time.Now()}}).Decode(&slot)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return slot, ErrTourSlotNotFound
+	}
+	if err != nil {
+		return slot, err
+	}
+	active, err := database.Collection("TourSlotRule").CountDocuments(context.TODO(), bson.M{"_id": slot.RuleID, "active": true})
+	if err != nil {
+		return slot, err
+	}
+	if active == 0 {
+		return slot, ErrTourSlotNotFound
+	}
+
+	err = collection.FindOneAndUpdate(context.TODO(),
+		bson.M{
+			"_id":      slotID,
+			"start_at": bson.M{"$gt": time.Now()},
+			"$expr":    bson.M{"$lt": bson.A{"$booked", "$capacity"}},
+		},
+		bson.M{"$inc": bson.M{"booked": 1}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&slot)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return slot, ErrTourSlotFull
+	}
+	return slot, err
+}
+
+func releaseTourSlot(db *mongo.Client, slotID primitive.ObjectID) {
+	_, err := db.Database(config.DBName).Collection("TourSlot").UpdateOne(context.TODO(),
+		bson.M{"_id": slotID, "booked": bson.M{"$gt": 0}},
+		bson.M{"$inc": bson.M{"booked": -1}},
+	)
+	if err != nil {
+		log.Printf("Warning: could not release a place on tour slot %s: %v", slotID.Hex(), err)
+	}
+}
+
+func formatTourTime(t time.Time) string {
+	return t.In(TourLocation()).Format("2006-01-02 15:04")
+}
+
+// BookTour reserves a place on a slot for a candidate who has no tour booked
+// yet, moves the candidate to tour_scheduled where the funnel allows it and
+// records the booking on the candidate's timeline.
+func BookTour(db *mongo.Client, candidateID primitive.ObjectID, slotID primitive.ObjectID, authorID string, authorName string) (models.TourBooking, error) {
+	candidate, err := FindCandidate(db, candidateID)
+	if err != nil {
+		return models.TourBooking{}, err
+	}
+
+	slot, err := reserveTourSlot(db, slotID)
+	if err != nil {
+		return models.TourBooking{}, err
+	}
+
+	now := time.Now()
+	booking := models.TourBooking{
+		ID:          primitive.NewObjectID(),
+		SlotID:      slot.ID,
+		CandidateID: candidateID,
+		Campus:      slot.Campus,
+		StartAt:     slot.StartAt,
+		Status:      models.TourBookingBooked,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	database := db.Database(config.DBName)
+	// A unique index allows one active booking per candidate
+	if _, err := database.Collection("TourBooking").InsertOne(context.TODO(), booking); err != nil {
+		releaseTourSlot(db, slot.ID)
+		if mongo.IsDuplicateKeyError(err) {
+			return booking, ErrTourAlreadyBooked
+		}
+		return booking, err
+	}
+
+	setCandidateTour(db, candidateID, &booking)
+	recordTourActivity(db, candidateID, fmt.Sprintf("Tour booked at %s on %s", slot.Campus, formatTourTime(slot.StartAt)), authorID, authorName)
+	if CanTransitionCandidate(candidate.Status, models.CandidateStatusTourScheduled) {
+		_, err := TransitionCandidate(db, candidateID, models.CandidateStatusTourScheduled, "", authorID, authorName)
+		if err != nil {
+			log.Printf("Warning: could not move candidate %s to tour_scheduled: %v", candidateID.Hex(), err)
+		}
+	}
+	return booking, nil
+}
+
+func findActiveBooking(db *mongo.Client, bookingID primitive.ObjectID) (models.TourBooking, error) {
+	var booking models.TourBooking
+	err := db.Database(config.DBName).Collection("TourBooking").FindOne(context.TODO(),
+		bson.M{"_id": bookingID, "status": models.TourBookingBooked},
+	).Decode(&booking)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return booking, ErrTourBookingMissing
+	}
+	return booking, err
+}
+
+// RescheduleTour moves a booking to another slot. The new place is taken
+// before the old one is given back, so a full slot leaves the booking as it was.
+func RescheduleTour(db *mongo.Client, bookingID primitive.ObjectID, slotID primitive.ObjectID, authorID string, authorName string) (models.TourBooking, error) {
+	booking, err := findActiveBooking(db, bookingID)
+	if err != nil {
+		return booking, err
+	}
+	if booking.SlotID == slotID {
+		return booking, nil
+	}
+
+	slot, err := reserveTourSlot(db, slotID)
+	if err != nil {
+		return booking, err
+	}
+
+	previous := booking
+	booking.SlotID = slot.ID
+	booking.Campus = slot.Campus
+	booking.StartAt = slot.StartAt
+	booking.UpdatedAt = time.Now()
+	result, err := db.Database(config.DBName).Collection("TourBooking").UpdateOne(context.TODO(),
+		bson.M{"_id": bookingID, "slot_id": previous.SlotID, "status": models.TourBookingBooked},
+		bson.M{"$set": bson.M{"slot_id": slot.ID, "campus": slot.Campus, "start_at": slot.StartAt, "updated_at": booking.UpdatedAt}},
+	)
+	if err != nil || result.MatchedCount == 0 {
+		releaseTourSlot(db, slot.ID)
+		if err == nil {
+			err = ErrTourBookingMissing
+		}
+		return previous, err
+	}
+	releaseTourSlot(db, previous.SlotID)
+
+	setCandidateTour(db, booking.CandidateID, &booking)
+	recordTourActivity(db, booking.CandidateID, fmt.Sprintf("Tour moved from %s on %s to %s on %s",
+		previous.Campus, formatTourTime(previous.StartAt), slot.Campus, formatTourTime(slot.StartAt)), authorID, authorName)
+	return booking, nil
+}
+
+// CancelTour cancels a booking and gives its place back.
+func CancelTour(db *mongo.Client, bookingID primitive.ObjectID, reason string, authorID string, authorName string) (models.TourBooking, error) {
+	var booking models.TourBooking
+	err := db.Database(config.DBName).Collection("TourBooking").FindOneAndUpdate(context.TODO(),
+		bson.M{"_id": bookingID, "status": models.TourBookingBooked},
+		bson.M{"$set": bson.M{"status": models.TourBookingCancelled, "cancel_reason": reason, "updated_at": time.Now()}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&booking)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return booking, ErrTourBookingMissing
+	}
+	if err != nil {
+		return booking, err
+	}
+	releaseTourSlot(db, booking.SlotID)
+
+	setCandidateTour(db, booking.CandidateID, nil)
+	content := fmt.Sprintf("Tour at %s on %s cancelled", booking.Campus, formatTourTime(booking.StartAt))
+	if reason != "" {
+		content += ": " + reason
+	}
+	recordTourActivity(db, booking.CandidateID, content, authorID, authorName)
+	return booking, nil
+}
+
+// CancelCandidateTours cancels the active booking of a candidate, if any.
+func CancelCandidateTours(db *mongo.Client, candidateID primitive.ObjectID, reason string, authorID string, authorName string) error {
+	var booking models.TourBooking
+	err := db.Database(config.DBName).Collection("TourBooking").FindOne(context.TODO(),
+		bson.M{"candidate_id": candidateID, "status": models.TourBookingBooked},
+	).Decode(&booking)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	_, err = CancelTour(db, booking.ID, reason, authorID, authorName)
+	return err
+}
+
+// ListTourBookings returns the bookings of tours between from and to,
+// optionally of one campus, soonest first.
+func ListTourBookings(db *mongo.Client, campus string, from time.Time, to time.Time, includeCancelled bool) ([]models.TourBooking, error) {
+	filter := bson.M{"start_at": bson.M{"$gte": from, "$lte": to}}
+	if campus != "" {
+		filter["campus"] = campus
+	}
+	if !includeCancelled {
+		filter["status"] = models.TourBookingBooked
+	}
+	cursor, err := db.Database(config.DBName).Collection("TourBooking").Find(context.TODO(), filter)
+	if err != nil {
+		return nil, err
+	}
+	bookings := make([]models.TourBooking, 0)
+	if err := cursor.All(context.TODO(), &bookings); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(bookings, func(i, j int) bool { return bookings[i].StartAt.Before(bookings[j].StartAt) })
+	return bookings, nil
+}
+
+func setCandidateTour(db *mongo.Client, candidateID primitive.ObjectID, booking *models.TourBooking) {
+	update := bson.M{"$unset": bson.M{"tour": ""}}
+	if booking != nil {
+		update = bson.M{"$set": bson.M{"tour": models.CandidateTour{
+			BookingID: booking.ID,
+			Campus:    booking.Campus,
+			StartAt:   booking.StartAt,
+		}}}
+	}
+	if _, err := db.Database(config.DBName).Collection("Candidate").UpdateByID(context.TODO(), candidateID, update); err != nil {
+		log.Printf("Warning: could not update the tour of candidate %s: %v", candidateID.Hex(), err)
+	}
+}
+
+func recordTourActivity(db *mongo.Client, candidateID primitive.ObjectID, content string, authorID string, authorName string) {
+	_, err := AddCandidateActivity(db, models.CandidateActivity{
+		CandidateID: candidateID,
+		Type:        models.CandidateActivityTour,
+		Content:     content,
+		AuthorID:    authorID,
+		AuthorName:  authorName,
+	})
+	if err != nil {
+		log.Printf("Warning: could not record tour change of candidate %s: %v", candidateID.Hex(), err)
+	}
+}
@@ -0,0 +1,364 @@
+package service
+
+import (
+	"amg-backend/config"
+	"amg-backend/models"
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"os"
+	"sort"
+	"time"
+)
+
+var ErrUnknownTrashType = errors.New("unknown trash item type")
+
+// trashCollection describes how a soft-deletable collection is stored.
+type trashCollection struct {
+	Collection    string
+	UpdatedField  string
+	TitleField    string
+	DefaultStatus string
+}
+
+var trashCollections = map[string]trashCollection{
+	models.TrashTypePost:      {Collection: "Post", UpdatedField: "update_at", TitleField: "title", DefaultStatus: models.PostStatusActive},
+	models.TrashTypeCandidate: {Collection: "Candidate", UpdatedField: "update_at", TitleField: "student_name", DefaultStatus: models.CandidateStatusNew},
+	models.TrashTypeComment:   {Collection: "Comment", UpdatedField: "updated_at", TitleField: "content", DefaultStatus: "new"},
+}
+
+// TrashRetention returns how long deleted items are kept before being purged.
+func TrashRetention() time.Duration {
+	days := config.TrashRetentionDays
+	if days <= 0 {
+		days = 30
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+func lookupTrashCollection(itemType string) (trashCollection, error) {
+	tc, ok := trashCollections[itemType]
+	if !ok {
+		return trashCollection{}, ErrUnknownTrashType
+	}
+	return tc, nil
+}
+
+// MoveToTrash soft-deletes a document, remembering its status so that it can
+// be restored. It reports whether a document was found.
+func MoveToTrash(db *mongo.Client, itemType string, id primitive.ObjectID) (bool, error) {
+	tc, err := lookupTrashCollection(itemType)
+	if err != nil {
+		return false, err
+	}
+
+	collection := db.Database(config.DBName).Collection(tc.Collection)
+	now := time.Now()
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"previous_status": "$status",
+			"status":          models.StatusDeleted,
+			"deleted_at":      now,
+			tc.UpdatedField:   now,
+		}}},
+	}
+	result, err := collection.UpdateOne(context.TODO(), bson.M{"_id": id, "status": bson.M{"$ne": models.StatusDeleted}}, update)
+	if err != nil {
+		return false, err
+	}
+	if result.MatchedCount > 0 {
+		if itemType == models.TrashTypeComment {
+			refreshCommentStatsOfComment(db, id)
+		}
+		if itemType == models.TrashTypeCandidate {
+			// The place is given back at once rather than held until the purge
+			if err := CancelCandidateTours(db, id, "candidate deleted", "", SystemAuthor); err != nil {
+				log.Printf("Warning: could not cancel the tour of deleted candidate %s: %v", id.Hex(), err)
+			}
+		}
+		return true, nil
+	}
+
+	// Already in the trash counts as found
+	count, err := collection.CountDocuments(context.TODO(), bson.M{"_id": id})
+	return count > 0, err
+}
+
+// RestoreFromTrash puts a soft-deleted document back into the status it had
+// before deletion. It reports whether a deleted document was found.
+func RestoreFromTrash(db *mongo.Client, itemType string, id primitive.ObjectID) (bool, error) {
+	found, err := RestoreFromTrashBatch(db, itemType, id)
+	if found && itemType == models.TrashTypePost {
+		RefreshRelatedPostsAsync(db)
+	}
+	return found, err
+}
+
+// RestoreFromTrashBatch restores a document like RestoreFromTrash but leaves
+// refreshing related posts to the caller, which restores many posts and
+// refreshes them once at the end.
+func RestoreFromTrashBatch(db *mongo.Client, itemType string, id primitive.ObjectID) (bool, error) {
+	tc, err := lookupTrashCollection(itemType)
+	if err != nil {
+		return false, err
+	}
+
+	collection := db.Database(config.DBName).Collection(tc.Collection)
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"status":        bson.M{"$ifNull": bson.A{"$previous_status", tc.DefaultStatus}},
+			tc.UpdatedField: time.Now(),
+		}}},
+		{{Key: "$unset", Value: bson.A{"previous_status", "deleted_at"}}},
+	}
+	result, err := collection.UpdateOne(context.TODO(), bson.M{"_id": id, "status": models.StatusDeleted}, update)
+	if err != nil {
+		return false, err
+	}
+	if result.MatchedCount == 0 {
+		return false, nil
+	}
+
+	if itemType == models.TrashTypePost {
+		var post models.Post
+		if err := collection.FindOne(context.TODO(), bson.M{"_id": id}).Decode(&post); err == nil {
+			if err := MarkImagesAsUsed(db, post.Content); err != nil {
+				log.Printf("Warning: could not mark images of restored post %s as used: %v", id.Hex(), err)
+			}
+			if err := SetPostAttachmentsStatus(db, id, models.AttachmentStatusUsed); err != nil {
+				log.Printf("Warning: could not mark attachments of restored post %s as used: %v", id.Hex(), err)
+			}
+		}
+	}
+	if itemType == models.TrashTypeComment {
+		refreshCommentStatsOfComment(db, id)
+	}
+	return true, nil
+}
+
+// ListTrash returns the soft-deleted documents of the given type, or of all
+// types when itemType is empty, most recently deleted first.
+func ListTrash(db *mongo.Client, itemType string) ([]models.TrashItem, error) {
+	types := make([]string, 0, len(trashCollections))
+	if itemType != "" {
+		if _, err := lookupTrashCollection(itemType); err != nil {
+			return nil, err
+		}
+		types = append(types, itemType)
+	} else {
+		for t := range trashCollections {
+			types = append(types, t)
+		}
+	}
+
+	retention := TrashRetention()
+	items := make([]models.TrashItem, 0)
+	for _, t := range types {
+		tc := trashCollections[t]
+		collection := db.Database(config.DBName).Collection(tc.Collection)
+		findOptions := options.Find().SetProjection(bson.M{tc.TitleField: 1, "deleted_at": 1, tc.UpdatedField: 1})
+		cursor, err := collection.Find(context.TODO(), bson.M{"status": models.StatusDeleted}, findOptions)
+		if err != nil {
+			return nil, err
+		}
+
+		var docs []bson.M
+		err = cursor.All(context.TODO(), &docs)
+		cursor.Close(context.TODO())
+		if err != nil {
+			return nil, err
+		}
+
+		for _, doc := range docs {
+			id, _ := doc["_id"].(primitive.ObjectID)
+			title, _ := doc[tc.TitleField].(string)
+			deletedAt := trashDeletedAt(doc, tc)
+			items = append(items, models.TrashItem{
+				ID:        id,
+				Type:      t,
+				Title:     BuildExcerpt(title, 120),
+				DeletedAt: deletedAt,
+				PurgeAt:   deletedAt.Add(retention),
+			})
+		}
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].DeletedAt.After(items[j].DeletedAt)
+	})
+	return items, nil
+}
+
+// trashDeletedAt falls back to the last update time for documents deleted
+// before deleted_at was recorded.
+func trashDeletedAt(doc bson.M, tc trashCollection) time.Time {
+	for _, field := range []string{"deleted_at", tc.UpdatedField} {
+		if dt, ok := doc[field].(primitive.DateTime); ok {
+			return dt.Time()
+		}
+	}
+	return time.Time{}
+}
+
+// PurgeTrashItem permanently deletes one soft-deleted document. It reports
+// whether a deleted document was found.
+func PurgeTrashItem(db *mongo.Client, itemType string, id primitive.ObjectID) (bool, error) {
+	tc, err := lookupTrashCollection(itemType)
+	if err != nil {
+		return false, err
+	}
+
+	collection := db.Database(config.DBName).Collection(tc.Collection)
+	var doc bson.M
+	err = collection.FindOne(context.TODO(), bson.M{"_id": id, "status": models.StatusDeleted}).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if itemType == models.TrashTypePost {
+		var post models.Post
+		if err := collection.FindOne(context.TODO(), bson.M{"_id": id}).Decode(&post); err != nil {
+			return false, err
+		}
+		releasePostResources(db, post)
+	}
+	if itemType == models.TrashTypeCandidate {
+		// Candidates trashed before tours were cancelled on deletion
+		if err := CancelCandidateTours(db, id, "candidate deleted", "", SystemAuthor); err != nil {
+			return false, err
+		}
+		if _, err := db.Database(config.DBName).Collection("CandidateActivity").DeleteMany(context.TODO(), bson.M{"candidate_id": id}); err != nil {
+			return false, err
+		}
+	}
+	if itemType == models.TrashTypeComment {
+		// Replies move up to the purged comment's parent instead of being orphaned
+		parentId, _ := doc["parent_id"].(string)
+		_, err := collection.UpdateMany(context.TODO(),
+			bson.M{"parent_id": id.Hex()},
+			bson.M{"$set": bson.M{"parent_id": parentId}},
+		)
+		if err != nil {
+			return false, err
+		}
+		if err := deleteCommentData(db, []primitive.ObjectID{id}); err != nil {
+			return false, err
+		}
+	}
+
+	_, err = collection.DeleteOne(context.TODO(), bson.M{"_id": id, "status": models.StatusDeleted})
+	return err == nil, err
+}
+
+// releasePostResources hands the images and attachments of a purged post over
+// to the cleanup jobs and removes the data that only made sense with the post.
+func releasePostResources(db *mongo.Client, post models.Post) {
+	database := db.Database(config.DBName)
+
+	// Imported header images are recorded too; the os.Remove below takes the
+	// file, the cleanup job the record
+	if urls := ExtractImageUrls(post.Content + "\n" + post.HeaderImage); len(urls) > 0 {
+		_, err := database.Collection("UploadedImage").UpdateMany(context.TODO(),
+			bson.M{"url": bson.M{"$in": urls}},
+			bson.M{"$set": bson.M{"status": models.ImageStatusPending}},
+		)
+		if err != nil {
+			log.Printf("Warning: could not release images of purged post %s: %v", post.ID.Hex(), err)
+		}
+	}
+	if _, err := LinkAttachments(db, post.ID, []primitive.ObjectID{}); err != nil {
+		log.Printf("Warning: could not release attachments of purged post %s: %v", post.ID.Hex(), err)
+	}
+	if post.HeaderImage != "" {
+		if err := os.Remove("." + post.HeaderImage); err != nil && !os.IsNotExist(err) {
+			log.Printf("Warning: could not delete header image %s: %v", post.HeaderImage, err)
+		}
+	}
+
+	for _, name := range []string{"PostView", "PostViewDaily", "RelatedPosts"} {
+		if _, err := database.Collection(name).DeleteMany(context.TODO(), bson.M{"post_id": post.ID}); err != nil {
+			log.Printf("Warning: could not delete %s of purged post %s: %v", name, post.ID.Hex(), err)
+		}
+	}
+	var commentIDs []primitive.ObjectID
+	cursor, err := database.Collection("Comment").Find(context.TODO(),
+		bson.M{"post_id": post.ID.Hex()}, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err == nil {
+		var comments []models.Comment
+		if err := cursor.All(context.TODO(), &comments); err == nil {
+			for _, comment := range comments {
+				commentIDs = append(commentIDs, comment.ID)
+			}
+		}
+	}
+	if len(commentIDs) > 0 {
+		if err := deleteCommentData(db, commentIDs); err != nil {
+			log.Printf("Warning: could not delete comment data of purged post %s: %v", post.ID.Hex(), err)
+		}
+	}
+	if _, err := database.Collection("Comment").DeleteMany(context.TODO(), bson.M{"post_id": post.ID.Hex()}); err != nil {
+		log.Printf("Warning: could not delete comments of purged post %s: %v", post.ID.Hex(), err)
+	}
+}
+
+// deleteCommentData removes what is stored alongside purged comments: their
+// edit history, reactions and reports.
+func deleteCommentData(db *mongo.Client, commentIDs []primitive.ObjectID) error {
+	database := db.Database(config.DBName)
+	for _, name := range []string{"CommentRevision", "CommentReaction", "CommentReport"} {
+		if _, err := database.Collection(name).DeleteMany(context.TODO(), bson.M{"comment_id": bson.M{"$in": commentIDs}}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PurgeExpiredTrash permanently deletes every document that has been in the
+// trash for longer than the retention period. It returns the number purged.
+func PurgeExpiredTrash(db *mongo.Client) (int, error) {
+	cutoff := time.Now().Add(-TrashRetention())
+	purged := 0
+
+	for itemType, tc := range trashCollections {
+		collection := db.Database(config.DBName).Collection(tc.Collection)
+		filter := bson.M{
+			"status": models.StatusDeleted,
+			"$or": bson.A{
+				bson.M{"deleted_at": bson.M{"$lt": cutoff}},
+				bson.M{"deleted_at": bson.M{"$exists": false}, tc.UpdatedField: bson.M{"$lt": cutoff}},
+			},
+		}
+		cursor, err := collection.Find(context.TODO(), filter, options.Find().SetProjection(bson.M{"_id": 1}))
+		if err != nil {
+			return purged, err
+		}
+
+		var docs []struct {
+			ID primitive.ObjectID `bson:"_id"`
+		}
+		err = cursor.All(context.TODO(), &docs)
+		cursor.Close(context.TODO())
+		if err != nil {
+			return purged, err
+		}
+
+		for _, doc := range docs {
+			ok, err := PurgeTrashItem(db, itemType, doc.ID)
+			if err != nil {
+				log.Printf("Warning: could not purge %s %s: %v", itemType, doc.ID.Hex(), err)
+				continue
+			}
+			if ok {
+				purged++
+			}
+		}
+	}
+	return purged, nil
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"amg-backend/config"
+	"amg-backend/models"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"strings"
+	"time"
+)
+
+// ViewDayLayout is the layout of PostViewDaily.Day, always in UTC.
+const ViewDayLayout = "2006-01-02"
+
+var botUserAgentMarkers = []string{
+	"bot", "crawl", "spider", "slurp", "facebookexternalhit", "embedly", "preview",
+	"headless", "lighthouse", "curl", "wget", "python-requests", "go-http-client",
+	"okhttp", "axios", "java/", "zalo-link",
+}
+
+// IsBotUserAgent reports whether a user agent looks like a crawler or a script.
+// Requests without a user agent are treated as bots.
+func IsBotUserAgent(userAgent string) bool {
+	ua := strings.ToLower(strings.TrimSpace(userAgent))
+	if ua == "" {
+		return true
+	}
+	for _, marker := range botUserAgentMarkers {
+		if strings.Contains(ua, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// VisitorID derives an anonymous visitor identifier from the client IP and user agent.
+func VisitorID(ip string, userAgent string) string {
+	sum := sha256.Sum256([]byte(ip + "|" + userAgent))
+	return hex.EncodeToString(sum[:16])
+}
+
+// RecordPostView counts a view of the post once per visitor per day. It
+// reports whether the view was counted.
+func RecordPostView(db *mongo.Client, postID primitive.ObjectID, ip string, userAgent string) (bool, error) {
+	if IsBotUserAgent(userAgent) {
+		return false, nil
+	}
+
+	now := time.Now().UTC()
+	day := now.Format(ViewDayLayout)
+	database := db.Database(config.DBName)
+
+	view := models.PostView{
+		ID:        primitive.NewObjectID(),
+		PostID:    postID,
+		VisitorID: VisitorID(ip, userAgent),
+		Day:       day,
+		CreatedAt: now,
+	}
+	if _, err := database.Collection("PostView").InsertOne(context.TODO(), view); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	_, err := database.Collection("PostViewDaily").UpdateOne(
+		context.TODO(),
+		bson.M{"post_id": postID, "day": day},
+		bson.M{"$inc": bson.M{"views": 1}},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return true, err
+	}
+
+	_, err = database.Collection("Post").UpdateByID(context.TODO(), postID, bson.M{"$inc": bson.M{"view_count": 1}})
+	return true, err
+}
+
+// GetPopularPosts returns the public active posts with the most views over the last
+// `days` days (today included), most viewed first.
+func GetPopularPosts(db *mongo.Client, days int, limit int) ([]models.PopularPost, error) {
+	since := time.Now().UTC().AddDate(0, 0, -(days - 1)).Format(ViewDayLayout)
+	database := db.Database(config.DBName)
+
+	// Over-fetch so that posts which are no longer active can be skipped
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"day": bson.M{"$gte": since}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$post_id", "views": bson.M{"$sum": "$views"}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "views", Value: -1}, {Key: "_id", Value: -1}}}},
+		{{Key: "$limit", Value: limit * 3}},
+	}
+	cursor, err := database.Collection("PostViewDaily").Aggregate(context.TODO(), pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	var totals []struct {
+		PostID primitive.ObjectID `bson:"_id"`
+		Views  int64              `bson:"views"`
+	}
+	if err := cursor.All(context.TODO(), &totals); err != nil {
+		return nil, err
+	}
+
+	popular := make([]models.PopularPost, 0, limit)
+	if len(totals) == 0 {
+		return popular, nil
+	}
+
+	ids := make([]primitive.ObjectID, len(totals))
+	for i, total := range totals {
+		ids[i] = total.PostID
+	}
+	filter := PublicPostFilter()
+	filter["_id"] = bson.M{"$in": ids}
+	filter["status"] = models.PostStatusActive
+	postCursor, err := database.Collection("Post").Find(context.TODO(), filter)
+	if err != nil {
+		return nil, err
+	}
+	defer postCursor.Close(context.TODO())
+
+	var posts []models.Post
+	if err := postCursor.All(context.TODO(), &posts); err != nil {
+		return nil, err
+	}
+	postsByID := make(map[primitive.ObjectID]models.Post, len(posts))
+	for _, post := range posts {
+		postsByID[post.ID] = post
+	}
+
+	for _, total := range totals {
+		post, ok := postsByID[total.PostID]
+		if !ok {
+			continue
+		}
+		popular = append(popular, models.PopularPost{Post: post, Views: total.Views})
+		if len(popular) == limit {
+			break
+		}
+	}
+	return popular, nil
+}
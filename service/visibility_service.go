@@ -0,0 +1,157 @@
+package service
+
+import (
+	"amg-backend/config"
+	"amg-backend/models"
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"strings"
+)
+
+// PostViewer describes who is reading posts. The zero value is an anonymous
+// visitor, who only sees public posts.
+type PostViewer struct {
+	LoggedIn bool
+	Staff    bool
+	Classes  []string
+}
+
+// LoadPostViewer builds the viewer for a logged-in user. Staff see every post;
+// other users are looked up for the classes their children are in.
+func LoadPostViewer(db *mongo.Client, userID string, role string) PostViewer {
+	viewer := PostViewer{LoggedIn: true, Staff: models.IsStaffRole(role)}
+	if viewer.Staff {
+		return viewer
+	}
+
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return viewer
+	}
+	var user models.User
+	err = db.Database(config.DBName).Collection("User").FindOne(context.TODO(),
+		bson.M{"_id": id, "is_active": true},
+		options.FindOne().SetProjection(bson.M{"classes": 1}),
+	).Decode(&user)
+	if err != nil {
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			log.Printf("Warning: could not load classes of user %s: %v", userID, err)
+		}
+		// Deactivated or deleted accounts are treated as visitors
+		return PostViewer{}
+	}
+	viewer.Classes = user.Classes
+	return viewer
+}
+
+// publicVisibilities matches posts created before visibility existed, too.
+var publicVisibilities = bson.A{nil, "", models.VisibilityPublic}
+
+// PublicPostFilter is the visibility condition for anonymous visitors. It is
+// also used for shared lists such as popular and related posts, feeds and
+// sitemaps, which must never expose restricted posts.
+func PublicPostFilter() bson.M {
+	return bson.M{"visibility": bson.M{"$in": publicVisibilities}}
+}
+
+// ApplyVisibilityFilter restricts a post query to what the viewer may read.
+// The condition is added under $and, so conditions already in the filter on
+// visibility or $or are kept.
+func ApplyVisibilityFilter(filter bson.M, viewer PostViewer) bson.M {
+	if viewer.Staff {
+		return filter
+	}
+	if !viewer.LoggedIn {
+		return addFilterCondition(filter, PublicPostFilter())
+	}
+
+	visible := append(bson.A{models.VisibilityMembers}, publicVisibilities...)
+	if len(viewer.Classes) == 0 {
+		return addFilterCondition(filter, bson.M{"visibility": bson.M{"$in": visible}})
+	}
+	return addFilterCondition(filter, bson.M{"$or": bson.A{
+		bson.M{"visibility": bson.M{"$in": visible}},
+		bson.M{"visibility": models.VisibilityClasses, "visible_to_classes": bson.M{"$in": viewer.Classes}},
+	}})
+}
+
+// addFilterCondition ands a condition onto a query filter.
+func addFilterCondition(filter bson.M, condition bson.M) bson.M {
+	switch and := filter["$and"].(type) {
+	case nil:
+		filter["$and"] = bson.A{condition}
+	case bson.A:
+		filter["$and"] = append(and, condition)
+	case []bson.M:
+		filter["$and"] = append(and, condition)
+	default:
+		filter["$and"] = bson.A{bson.M{"$and": and}, condition}
+	}
+	return filter
+}
+
+// CanViewPost reports whether the viewer may read the post.
+func CanViewPost(viewer PostViewer, post models.Post) bool {
+	if viewer.Staff {
+		return true
+	}
+	switch post.Visibility {
+	case "", models.VisibilityPublic:
+		return true
+	case models.VisibilityMembers:
+		return viewer.LoggedIn
+	case models.VisibilityClasses:
+		for _, class := range post.VisibleToClasses {
+			for _, own := range viewer.Classes {
+				if class == own {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// PostVisibleTo loads a post and reports whether the viewer may read it and
+// what comes with it (attachments, comments). Posts that are not active are
+// only visible to staff.
+func PostVisibleTo(db *mongo.Client, postID primitive.ObjectID, viewer PostViewer) (bool, error) {
+	var post models.Post
+	err := db.Database(config.DBName).Collection("Post").FindOne(context.TODO(),
+		bson.M{"_id": postID},
+		options.FindOne().SetProjection(bson.M{"status": 1, "visibility": 1, "visible_to_classes": 1}),
+	).Decode(&post)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if post.Status != models.PostStatusActive && !viewer.Staff {
+		return false, nil
+	}
+	return CanViewPost(viewer, post), nil
+}
+
+// NormalizeVisibility validates a visibility and its class list. An empty
+// visibility means public; classes are only kept for class-restricted posts.
+func NormalizeVisibility(visibility string, classes []string) (string, []string, bool) {
+	visibility = strings.ToLower(strings.TrimSpace(visibility))
+	switch visibility {
+	case "", models.VisibilityPublic:
+		return models.VisibilityPublic, nil, true
+	case models.VisibilityMembers:
+		return models.VisibilityMembers, nil, true
+	case models.VisibilityClasses:
+		if len(classes) == 0 {
+			return "", nil, false
+		}
+		return models.VisibilityClasses, classes, true
+	}
+	return "", nil, false
+}